@@ -0,0 +1,169 @@
+package alerting
+
+import (
+	"log"
+	"sync"
+	"time"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//Notification is the payload sent to the webhooks when an alert fires or resolves
+//threshold alerts contain the rule, no-data alerts the sensor
+type Notification struct {
+	Alert  *storage.Alert      `json:"alert"`
+	Rule   *storage.AlertRule  `json:"rule,omitempty"`
+	Sensor *NotificationSensor `json:"sensor,omitempty"`
+}
+
+//NotificationSensor identifies the sensor of a notification without its settings, the name is empty if the sensor was deleted
+type NotificationSensor struct {
+	Id   uuid.UUID `json:"id"`
+	Name string    `json:"name,omitempty"`
+}
+
+//Evaluator checks the alert rules of a sensor against its new weather data
+type Evaluator struct {
+	registry storage.AlertRegistry
+	notifier Notifier
+	//pending holds per sensor and rule the time since when the threshold is breached, until the duration of the rule is reached
+	pending map[uuid.UUID]map[uuid.UUID]time.Time
+	mutex   sync.Mutex
+}
+
+//NewEvaluator Factory
+func NewEvaluator(registry storage.AlertRegistry, notifier Notifier) *Evaluator {
+	evaluator := new(Evaluator)
+	evaluator.registry = registry
+	evaluator.notifier = notifier
+	evaluator.pending = make(map[uuid.UUID]map[uuid.UUID]time.Time)
+	return evaluator
+}
+
+//Evaluate fires and resolves the alerts of the rules of the sensor, the sensor is nil for unregistered sensors
+func (evaluator *Evaluator) Evaluate(data *storage.WeatherData, sensor *storage.WeatherSensor) {
+	rules, err := evaluator.registry.GetRulesOfSensor(data.SensorId)
+	if err != nil {
+		return
+	}
+	if len(rules) == 0 {
+		evaluator.mutex.Lock()
+		delete(evaluator.pending, data.SensorId)
+		evaluator.mutex.Unlock()
+		return
+	}
+
+	openAlerts, err := evaluator.registry.GetOpenAlerts([]uuid.UUID{data.SensorId})
+	if err != nil {
+		return
+	}
+	openAlertOfRule := make(map[uuid.UUID]*storage.Alert)
+	for _, alert := range openAlerts {
+		if alert.Kind != storage.NoDataAlert {
+			openAlertOfRule[alert.RuleId] = alert
+		}
+	}
+
+	evaluator.mutex.Lock()
+	defer evaluator.mutex.Unlock()
+
+	//only the breaches of the current enabled rules stay pending, breaches of deleted rules are dropped
+	previous := evaluator.pending[data.SensorId]
+	pending := make(map[uuid.UUID]time.Time)
+	defer func() {
+		if len(pending) == 0 {
+			delete(evaluator.pending, data.SensorId)
+		} else {
+			evaluator.pending[data.SensorId] = pending
+		}
+	}()
+
+	for _, rule := range rules {
+		value, exists := valueOf(data, sensor, rule.ValueType)
+		if rule.Disabled {
+			//the firing alert of a disabled rule is resolved, otherwise it would stay open forever
+			if alert, isFiring := openAlertOfRule[rule.Id]; isFiring {
+				if !exists {
+					value = alert.Value
+				}
+				alert.Resolve(value, data.TimeStamp)
+				evaluator.publish(alert, rule)
+			}
+			continue
+		}
+		if !exists {
+			if since, isPending := previous[rule.Id]; isPending {
+				pending[rule.Id] = since
+			}
+			continue
+		}
+
+		if alert, isFiring := openAlertOfRule[rule.Id]; isFiring {
+			if rule.IsRecovered(value) {
+				alert.Resolve(value, data.TimeStamp)
+				evaluator.publish(alert, rule)
+			}
+			continue
+		}
+
+		if !rule.IsBreached(value) {
+			continue
+		}
+
+		since, isPending := previous[rule.Id]
+		if !isPending {
+			since = data.TimeStamp
+		}
+		if data.TimeStamp.Sub(since) >= time.Duration(rule.Duration)*time.Second {
+			evaluator.publish(storage.NewAlert(rule, value, data.TimeStamp), rule)
+		} else {
+			pending[rule.Id] = since
+		}
+	}
+}
+
+//RemoveRule resolves the firing alert of a deleted rule and notifies its webhooks, the pending breach of the rule is dropped
+func (evaluator *Evaluator) RemoveRule(rule *storage.AlertRule) {
+	evaluator.mutex.Lock()
+	if pending, exists := evaluator.pending[rule.SensorId]; exists {
+		delete(pending, rule.Id)
+		if len(pending) == 0 {
+			delete(evaluator.pending, rule.SensorId)
+		}
+	}
+	evaluator.mutex.Unlock()
+
+	openAlerts, err := evaluator.registry.GetOpenAlerts([]uuid.UUID{rule.SensorId})
+	if err != nil {
+		return
+	}
+	for _, alert := range openAlerts {
+		if alert.Kind != storage.NoDataAlert && alert.RuleId == rule.Id {
+			alert.Resolve(alert.Value, time.Now())
+			evaluator.publish(alert, rule)
+		}
+	}
+}
+
+//publish saves the alert and notifies the webhooks of the rule
+func (evaluator *Evaluator) publish(alert *storage.Alert, rule *storage.AlertRule) {
+	if err := evaluator.registry.SaveAlert(alert); err != nil {
+		log.Print(err)
+		return
+	}
+	evaluator.notifier.Notify(rule.Webhooks, Notification{Alert: alert, Rule: rule})
+}
+
+//valueOf returns the value of the type, derived values are computed if they are not part of the data
+func valueOf(data *storage.WeatherData, sensor *storage.WeatherSensor, valueType storage.SensorValueType) (float64, bool) {
+	if value, exists := data.Values[valueType]; exists {
+		return value, true
+	}
+	for _, derived := range storage.GetDerivedValues() {
+		if derived.Name == valueType {
+			return derived.Compute(data.Values, sensor)
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,154 @@
+package alerting
+
+import (
+	"log"
+	"sync"
+	"time"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//NoDataChecker periodically fires alerts for registered sensors that have not delivered data and resolves them when data returns
+type NoDataChecker struct {
+	sensorRegistry storage.SensorRegistry
+	statusRegistry storage.SensorStatusRegistry
+	alertRegistry  storage.AlertRegistry
+	notifier       Notifier
+	interval       time.Duration
+	stop           chan struct{}
+	wg             sync.WaitGroup
+}
+
+//NewNoDataChecker Factory, the checks run in the given interval after Start
+func NewNoDataChecker(sensorRegistry storage.SensorRegistry, statusRegistry storage.SensorStatusRegistry, alertRegistry storage.AlertRegistry, notifier Notifier, interval time.Duration) *NoDataChecker {
+	checker := new(NoDataChecker)
+	checker.sensorRegistry = sensorRegistry
+	checker.statusRegistry = statusRegistry
+	checker.alertRegistry = alertRegistry
+	checker.notifier = notifier
+	checker.interval = interval
+	checker.stop = make(chan struct{})
+	return checker
+}
+
+//Start runs the checks in the background until Close is called
+func (checker *NoDataChecker) Start() {
+	checker.wg.Add(1)
+	go func() {
+		defer checker.wg.Done()
+		ticker := time.NewTicker(checker.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				checker.Check(now)
+			case <-checker.stop:
+				return
+			}
+		}
+	}()
+}
+
+//Close stops the background checks
+func (checker *NoDataChecker) Close() {
+	close(checker.stop)
+	checker.wg.Wait()
+}
+
+//Check fires and resolves the no-data alerts of all registered sensors with settings, alerts of disabled settings are resolved
+//sensors which have never delivered data are silent since they were registered
+//open alerts of sensors without settings and of deleted sensors are resolved
+func (checker *NoDataChecker) Check(now time.Time) {
+	sensors, err := checker.sensorRegistry.GetSensors()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	checkedSensors := make([]*storage.WeatherSensor, 0)
+	sensorIds := make([]uuid.UUID, 0)
+	sensorOfId := make(map[uuid.UUID]*storage.WeatherSensor)
+	for _, sensor := range sensors {
+		sensorOfId[sensor.Id] = sensor
+		if sensor.NoDataAlert != nil {
+			checkedSensors = append(checkedSensors, sensor)
+			sensorIds = append(sensorIds, sensor.Id)
+		}
+	}
+
+	openAlerts, err := checker.alertRegistry.GetOpenAlertsOfKind(storage.NoDataAlert)
+	if err != nil {
+		return
+	}
+	openAlertOfSensor := make(map[uuid.UUID]*storage.Alert)
+	for _, alert := range openAlerts {
+		sensor, exists := sensorOfId[alert.SensorId]
+		if !exists || sensor.NoDataAlert == nil {
+			alert.Resolve(0, now)
+			checker.publish(alert, alert.Webhooks, alert.SensorId, sensor)
+			continue
+		}
+		openAlertOfSensor[alert.SensorId] = alert
+	}
+	if len(checkedSensors) == 0 {
+		return
+	}
+
+	statuses, err := checker.statusRegistry.GetStatuses(sensorIds)
+	if err != nil {
+		return
+	}
+	statusOfSensor := make(map[uuid.UUID]*storage.SensorStatus)
+	for _, status := range statuses {
+		statusOfSensor[status.SensorId] = status
+	}
+
+	for _, sensor := range checkedSensors {
+		//a sensor without data is silent since it was registered, sensors registered before the time was recorded are not checked
+		lastSeen := sensor.CreatedAt
+		status, exists := statusOfSensor[sensor.Id]
+		if exists && status.MessageCount != 0 {
+			lastSeen = status.LastSeen
+		} else if lastSeen.IsZero() {
+			continue
+		}
+
+		//the silence is only counted within the current reporting window of the schedule
+		silentSince := lastSeen
+		activeSince, active := sensor.NoDataAlert.ActiveSince(now, sensor.TimeLocation())
+		if activeSince.After(silentSince) {
+			silentSince = activeSince
+		}
+		silent := now.Sub(silentSince) >= sensor.NoDataTimeout()
+
+		webhooks := sensor.NoDataAlert.Webhooks
+		if alert, isFiring := openAlertOfSensor[sensor.Id]; isFiring {
+			if sensor.NoDataAlert.Disabled {
+				alert.Resolve(0, now)
+				checker.publish(alert, webhooks, sensor.Id, sensor)
+			} else if alert.FiredAt.Before(lastSeen) {
+				alert.Resolve(0, lastSeen)
+				checker.publish(alert, webhooks, sensor.Id, sensor)
+			}
+			continue
+		}
+
+		if silent && active && !sensor.NoDataAlert.Disabled {
+			checker.publish(storage.NewNoDataAlert(sensor, lastSeen, now), webhooks, sensor.Id, sensor)
+		}
+	}
+}
+
+//publish saves the alert and notifies the webhooks with the id and name of the sensor, the sensor is nil if it was deleted
+func (checker *NoDataChecker) publish(alert *storage.Alert, webhooks []string, sensorId uuid.UUID, sensor *storage.WeatherSensor) {
+	if err := checker.alertRegistry.SaveAlert(alert); err != nil {
+		log.Print(err)
+		return
+	}
+	notificationSensor := &NotificationSensor{Id: sensorId}
+	if sensor != nil {
+		notificationSensor.Name = sensor.Name
+	}
+	checker.notifier.Notify(webhooks, Notification{Alert: alert, Sensor: notificationSensor})
+}
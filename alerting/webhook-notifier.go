@@ -0,0 +1,137 @@
+package alerting
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+)
+
+//notificationWorkers is the number of parallel deliveries, so a slow webhook does not block all others
+var notificationWorkers = 4
+
+//Notifier delivers notifications to webhooks
+type Notifier interface {
+	Notify(webhooks []string, payload interface{})
+	Close()
+}
+
+type delivery struct {
+	url  string
+	body []byte
+}
+
+type webhookNotifier struct {
+	config config.NotificationConfig
+	client *http.Client
+	queue  chan delivery
+	done   chan struct{}
+	closed bool
+	mutex  sync.RWMutex
+	wg     sync.WaitGroup
+}
+
+//NewWebhookNotifier Factory, the notifications are posted as json and retried with increasing delay
+func NewWebhookNotifier(cfg config.NotificationConfig) *webhookNotifier {
+	notifier := new(webhookNotifier)
+	notifier.config = cfg
+	notifier.client = storage.NewWebhookClient(cfg.Timeout, cfg.AllowPrivateNetworks)
+	notifier.queue = make(chan delivery, cfg.QueueSize)
+	notifier.done = make(chan struct{})
+
+	for i := 0; i < notificationWorkers; i++ {
+		notifier.wg.Add(1)
+		go notifier.run()
+	}
+	return notifier
+}
+
+//Notify queues the payload for all webhooks, notifications are dropped if the queue is full
+func (notifier *webhookNotifier) Notify(webhooks []string, payload interface{}) {
+	if len(webhooks) == 0 {
+		return
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	notifier.mutex.RLock()
+	defer notifier.mutex.RUnlock()
+	if notifier.closed {
+		return
+	}
+
+	for _, webhook := range webhooks {
+		select {
+		case notifier.queue <- delivery{url: webhook, body: body}:
+		default:
+			log.Printf("notification queue is full, dropped notification to %v", webhook)
+		}
+	}
+}
+
+//Close stops the delivery, pending retries are cancelled
+func (notifier *webhookNotifier) Close() {
+	notifier.mutex.Lock()
+	if notifier.closed {
+		notifier.mutex.Unlock()
+		return
+	}
+	notifier.closed = true
+	close(notifier.done)
+	close(notifier.queue)
+	notifier.mutex.Unlock()
+
+	notifier.wg.Wait()
+}
+
+func (notifier *webhookNotifier) run() {
+	defer notifier.wg.Done()
+	for d := range notifier.queue {
+		if err := notifier.deliver(d); err != nil {
+			log.Printf("notification to %v failed: %v", d.url, err)
+		}
+	}
+}
+
+//deliver posts the notification, failed attempts are retried with a doubled delay
+func (notifier *webhookNotifier) deliver(d delivery) error {
+	var err error
+	delay := notifier.config.RetryDelay
+	for attempt := 0; attempt <= notifier.config.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+				delay *= 2
+			case <-notifier.done:
+				return fmt.Errorf("cancelled after %v attempts: %v", attempt, err)
+			}
+		}
+
+		if err = post(notifier.client, d.url, d.body); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+func post(client *http.Client, url string, body []byte) error {
+	resp, err := client.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return nil
+}
@@ -0,0 +1,254 @@
+package api
+
+import (
+	"net/http"
+	"strings"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+//canRead checks if the user is allowed to read the sensor and its weather data, anonymous users have an empty userId
+func (api *weatherRestApi) canRead(sensor *storage.WeatherSensor, userId string) bool {
+	return api.hasReadAccess(sensor.UserId, sensor.OrganizationId, sensor.Visibility, sensor.IsSharedWith, userId)
+}
+
+//canManage checks if the user is allowed to change the sensor and to add or change its weather data
+func (api *weatherRestApi) canManage(sensor *storage.WeatherSensor, userId string) bool {
+	return api.isOwner(sensor.UserId, sensor.OrganizationId, userId, true)
+}
+
+//CanReadSensor checks if the user is allowed to read the weather data of the registered sensor, used outside of the api
+func (api *weatherRestApi) CanReadSensor(sensorId uuid.UUID, userId string) bool {
+	sensor, err := api.sensorRegistry.GetSensor(sensorId)
+	return err == nil && api.canRead(sensor, userId)
+}
+
+//isSensorOwner checks if the user owns the sensor, either directly or as member of the owning organization with any role
+func (api *weatherRestApi) isSensorOwner(sensor *storage.WeatherSensor, userId string) bool {
+	return api.isOwner(sensor.UserId, sensor.OrganizationId, userId, false)
+}
+
+//canReadStation checks if the user is allowed to read the station, its weather data contains only the sensors readable by the user
+func (api *weatherRestApi) canReadStation(station *storage.Station, userId string) bool {
+	return api.hasReadAccess(station.UserId, station.OrganizationId, station.Visibility, station.IsSharedWith, userId)
+}
+
+//canManageStation checks if the user is allowed to change the station and its members
+func (api *weatherRestApi) canManageStation(station *storage.Station, userId string) bool {
+	return api.isOwner(station.UserId, station.OrganizationId, userId, true)
+}
+
+func (api *weatherRestApi) hasReadAccess(ownerId string, organizationId *uuid.UUID, visibility storage.SensorVisibility, isSharedWith func(string) bool, userId string) bool {
+	switch {
+	case visibility == storage.Public:
+		return true
+	case len(userId) == 0:
+		return false
+	case api.isOwner(ownerId, organizationId, userId, false):
+		return true
+	case visibility == storage.Shared:
+		return isSharedWith(userId)
+	}
+	return false
+}
+
+//isOwner checks if the user owns a sensor or station, either directly or as member of the owning organization
+//if manage is set, the role of the user has to allow changes within the organization
+func (api *weatherRestApi) isOwner(ownerId string, organizationId *uuid.UUID, userId string, manage bool) bool {
+	if len(userId) == 0 {
+		return false
+	}
+	if organizationId == nil {
+		return ownerId == userId
+	}
+	organization, err := api.organizationRegistry.GetOrganization(*organizationId)
+	if err != nil {
+		return false
+	}
+	role, isMember := organization.RoleOf(userId)
+	return isMember && (!manage || role.CanManage())
+}
+
+//assignOwner returns the owning user id of a new sensor or station, which is empty if it is owned by the organization
+//the user needs a role allowing changes within the organization, otherwise the error is written to the response
+func (api *weatherRestApi) assignOwner(w http.ResponseWriter, organizationId *uuid.UUID, userId string) (string, bool) {
+	if organizationId == nil {
+		return userId, true
+	}
+	if !api.isOwner("", organizationId, userId, true) {
+		http.Error(w, "", http.StatusForbidden)
+		return "", false
+	}
+	return "", true
+}
+
+//transferOwner keeps the owner if the organization is unchanged, otherwise the ownership is moved to the new organization or to the user
+func (api *weatherRestApi) transferOwner(w http.ResponseWriter, ownerId string, organizationId *uuid.UUID, newOrganizationId *uuid.UUID, userId string) (string, *uuid.UUID, bool) {
+	if sameOrganization(organizationId, newOrganizationId) {
+		return ownerId, organizationId, true
+	}
+	ownerId, ok := api.assignOwner(w, newOrganizationId, userId)
+	return ownerId, newOrganizationId, ok
+}
+
+func sameOrganization(a *uuid.UUID, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+//isAdmin checks if the user has the configured admin role
+func (api *weatherRestApi) isAdmin(r *http.Request) bool {
+	for _, role := range strings.Split(r.Header.Get(userRolesHeader), ",") {
+		if role == api.config.AdminRole {
+			return true
+		}
+	}
+	return false
+}
+
+//requestScope is the sensor registry and the weather storage restricted to the tenant of a request
+type requestScope struct {
+	sensorRegistry storage.SensorRegistry
+	weatherStorage storage.WeatherStorage
+}
+
+//tenantScope builds the scope of the caller of the request from its own, organization, shared and public sensors
+//it is built once per request and used for all its sensor and weather data queries
+func (api *weatherRestApi) tenantScope(r *http.Request) (*requestScope, error) {
+	userId := r.Header.Get(userIdHeader)
+	var organizations []*storage.Organization
+	if len(userId) != 0 {
+		var err error
+		if organizations, err = api.organizationRegistry.GetOrganizationsOfUser(userId); err != nil {
+			return nil, err
+		}
+	}
+
+	tenant := storage.NewTenant(userId, organizations, config.AllowUnregisteredSensors)
+	return &requestScope{
+		sensorRegistry: storage.NewScopedSensorRegistry(api.sensorRegistry, tenant),
+		weatherStorage: storage.NewScopedWeatherStorage(api.weaterStorage, api.sensorRegistry, tenant),
+	}, nil
+}
+
+//readableSensor resolves the sensor of the {id} route variable if the user is allowed to read it, otherwise the error is written to the response
+func (api *weatherRestApi) readableSensor(w http.ResponseWriter, r *http.Request) (*storage.WeatherSensor, bool) {
+	_, sensor, ok := api.authorizedSensor(w, r, api.canRead, false)
+	return sensor, ok
+}
+
+//manageableSensor resolves the sensor of the {id} route variable if the user is allowed to manage it, otherwise the error is written to the response
+func (api *weatherRestApi) manageableSensor(w http.ResponseWriter, r *http.Request) (*storage.WeatherSensor, bool) {
+	_, sensor, ok := api.authorizedSensor(w, r, api.canManage, false)
+	return sensor, ok
+}
+
+//ownedSensor resolves the sensor of the {id} route variable if the user is an owner, otherwise the error is written to the response
+func (api *weatherRestApi) ownedSensor(w http.ResponseWriter, r *http.Request) (*storage.WeatherSensor, bool) {
+	_, sensor, ok := api.authorizedSensor(w, r, api.isSensorOwner, false)
+	return sensor, ok
+}
+
+//weatherDataSensor resolves the sensor of the {id} route variable for accessing its weather data
+//if unregistered sensors are allowed, their weather data is accessible for all authenticated users and the returned sensor is nil
+func (api *weatherRestApi) weatherDataSensor(w http.ResponseWriter, r *http.Request, hasAccess func(*storage.WeatherSensor, string) bool) (uuid.UUID, *storage.WeatherSensor, bool) {
+	return api.authorizedSensor(w, r, hasAccess, config.AllowUnregisteredSensors)
+}
+
+//authorizedSensor resolves the sensor of the {id} route variable and checks the access
+//sensors without access are reported as not found, so their existence is not revealed
+func (api *weatherRestApi) authorizedSensor(w http.ResponseWriter, r *http.Request, hasAccess func(*storage.WeatherSensor, string) bool, allowUnregistered bool) (uuid.UUID, *storage.WeatherSensor, bool) {
+	userId := r.Header.Get(userIdHeader)
+
+	sensorId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return sensorId, nil, false
+	}
+
+	sensor, err := api.sensorRegistry.GetSensor(sensorId)
+	if err == nil && hasAccess(sensor, userId) {
+		return sensorId, sensor, true
+	}
+	if err != nil && allowUnregistered && len(userId) != 0 {
+		if api.isUnregistered(sensorId) {
+			return sensorId, nil, true
+		}
+	}
+
+	if len(userId) == 0 {
+		http.Error(w, "missing authorization token", http.StatusUnauthorized)
+	} else {
+		http.Error(w, "", http.StatusNotFound)
+	}
+	return sensorId, nil, false
+}
+
+//isUnregistered checks that the sensor is neither registered nor deleted within its restore window
+func (api *weatherRestApi) isUnregistered(sensorId uuid.UUID) bool {
+	if exists, err := api.sensorRegistry.ExistSensor(sensorId); err != nil || exists {
+		return false
+	}
+	_, err := api.sensorRegistry.GetDeletedSensor(sensorId)
+	return err != nil
+}
+
+//authorizedStation resolves the station of the {id} route variable and checks the access
+func (api *weatherRestApi) authorizedStation(w http.ResponseWriter, r *http.Request, hasAccess func(*storage.Station, string) bool) (*storage.Station, bool) {
+	userId := r.Header.Get(userIdHeader)
+
+	stationId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return nil, false
+	}
+
+	station, err := api.stationRegistry.GetStation(stationId)
+	if err == nil && hasAccess(station, userId) {
+		return station, true
+	}
+
+	if len(userId) == 0 {
+		http.Error(w, "missing authorization token", http.StatusUnauthorized)
+	} else {
+		http.Error(w, "", http.StatusNotFound)
+	}
+	return nil, false
+}
+
+//authorizedOrganization resolves the organization of the {id} route variable for members and admins
+//if manage is set, only admins of the organization or users with the admin role are allowed
+func (api *weatherRestApi) authorizedOrganization(w http.ResponseWriter, r *http.Request, manage bool) (*storage.Organization, bool) {
+	userId := r.Header.Get(userIdHeader)
+
+	organizationId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return nil, false
+	}
+
+	organization, err := api.organizationRegistry.GetOrganization(organizationId)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return nil, false
+	}
+	if api.isAdmin(r) {
+		return organization, true
+	}
+
+	role, isMember := organization.RoleOf(userId)
+	if !isMember {
+		http.Error(w, "", http.StatusNotFound)
+		return nil, false
+	}
+	if manage && role != storage.OrganizationAdmin {
+		http.Error(w, "", http.StatusForbidden)
+		return nil, false
+	}
+	return organization, true
+}
@@ -0,0 +1,224 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+//AlertRuleDeletedFunc Function-Signature for deleted alert rules
+type AlertRuleDeletedFunc func(*storage.AlertRule)
+
+//OnAlertRuleDeleted adds a function executed after an alert rule was deleted, e.g. to resolve its firing alert
+func (api *weatherRestApi) OnAlertRuleDeleted(callback AlertRuleDeletedFunc) {
+	api.onAlertRuleDeletedFunctions = append(api.onAlertRuleDeletedFunctions, callback)
+}
+
+func (api *weatherRestApi) getAlertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.ownedSensor(w, r)
+	if !ok {
+		return
+	}
+
+	rules, err := api.alertRegistry.GetRulesOfSensor(sensor.Id)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rules)
+}
+
+func (api *weatherRestApi) addAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	rule := new(storage.AlertRule)
+	err := json.NewDecoder(r.Body).Decode(rule)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	rule.SensorId = sensor.Id
+	if err = api.validateAlertRule(rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	rule, err = api.alertRegistry.AddRule(rule)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (api *weatherRestApi) getAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.ownedSensor(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := api.alertRuleOfSensor(w, r, sensor)
+	if !ok {
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+func (api *weatherRestApi) updateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := api.alertRuleOfSensor(w, r, sensor)
+	if !ok {
+		return
+	}
+	ruleId := rule.Id
+
+	err := json.NewDecoder(r.Body).Decode(rule)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	rule.Id = ruleId
+	rule.SensorId = sensor.Id
+	if err = api.validateAlertRule(rule); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = api.alertRegistry.UpdateRule(rule); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+//deleteAlertRuleHandler deletes the rule, firing alerts of the rule are resolved
+func (api *weatherRestApi) deleteAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	rule, ok := api.alertRuleOfSensor(w, r, sensor)
+	if !ok {
+		return
+	}
+
+	if err := api.alertRegistry.DeleteRule(rule.Id); err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	//the firing alert of the rule is resolved and notified by the functions, e.g. the alert evaluator
+	for _, function := range api.onAlertRuleDeletedFunctions {
+		function(rule)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//getAlertsHandler lists the alerts of the sensor, newest first, optionally limited by the state parameter
+func (api *weatherRestApi) getAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.ownedSensor(w, r)
+	if !ok {
+		return
+	}
+
+	alerts, err := api.alertRegistry.GetAlertsOfSensor(sensor.Id)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	state := storage.AlertState(r.URL.Query().Get("state"))
+	result := make([]*storage.Alert, 0)
+	for _, alert := range alerts {
+		if len(state) == 0 || alert.State == state {
+			result = append(result, alert)
+		}
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+//getOpenAlertsOverviewHandler lists the open alerts of the sensors of the user and of the organizations of the user
+//the list can be limited to a kind with the kind parameter, e.g. kind=nodata
+func (api *weatherRestApi) getOpenAlertsOverviewHandler(w http.ResponseWriter, r *http.Request) {
+	sensors, err := api.tenantSensors(r.Header.Get(userIdHeader))
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	sensorIds := make([]uuid.UUID, 0)
+	for _, sensor := range sensors {
+		sensorIds = append(sensorIds, sensor.Id)
+	}
+	alerts, err := api.alertRegistry.GetOpenAlerts(sensorIds)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	kind := storage.AlertKind(r.URL.Query().Get("kind"))
+	result := make([]*storage.Alert, 0)
+	for _, alert := range alerts {
+		if len(kind) == 0 || alert.Kind == kind {
+			result = append(result, alert)
+		}
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+//alertRuleOfSensor resolves the rule of the {ruleId} route variable, rules of other sensors are reported as not found
+func (api *weatherRestApi) alertRuleOfSensor(w http.ResponseWriter, r *http.Request, sensor *storage.WeatherSensor) (*storage.AlertRule, bool) {
+	ruleId, err := uuid.Parse(mux.Vars(r)["ruleId"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return nil, false
+	}
+
+	rule, err := api.alertRegistry.GetRule(ruleId)
+	if err != nil || rule.SensorId != sensor.Id {
+		http.Error(w, "", http.StatusNotFound)
+		return nil, false
+	}
+	return rule, true
+}
+
+//validateAlertRule checks the rule and that its value type is part of the catalog
+func (api *weatherRestApi) validateAlertRule(rule *storage.AlertRule) error {
+	if err := rule.Validate(); err != nil {
+		return err
+	}
+	_, err := api.valueTypeCatalog.GetValueType(rule.ValueType)
+	return err
+}
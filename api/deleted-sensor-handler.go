@@ -0,0 +1,83 @@
+package api
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+//getDeletedSensorsHandler lists the deleted sensors the user is allowed to restore
+func (api *weatherRestApi) getDeletedSensorsHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	sensors, err := api.sensorRegistry.GetDeletedSensors()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	result := make([]*storage.WeatherSensor, 0)
+	for _, sensor := range sensors {
+		if api.canManage(sensor, userId) {
+			result = append(result, sensor)
+		}
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+//restoreWeatherSensorHandler restores a deleted sensor within its restore window
+//the external ids of the sensor have to be unused, as they may have been assigned to another sensor in the meantime
+func (api *weatherRestApi) restoreWeatherSensorHandler(w http.ResponseWriter, r *http.Request) {
+	sensorId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	sensor, err := api.sensorRegistry.GetDeletedSensor(sensorId)
+	if err != nil || !api.canManage(sensor, r.Header.Get(userIdHeader)) {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	//the sensor is purged with the next run of the purger
+	if !time.Now().Before(sensor.Deletion.PurgeAt) {
+		http.Error(w, "restore window has ended", http.StatusGone)
+		return
+	}
+
+	if !api.availableExternalIds(w, sensor) {
+		return
+	}
+
+	stationIds := sensor.Deletion.StationIds
+	sensor, err = api.sensorRegistry.RestoreSensor(sensorId)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	//the sensor is added again to the stations it was removed from, as far as they still exist
+	for _, stationId := range stationIds {
+		station, err := api.stationRegistry.GetStation(stationId)
+		if err != nil {
+			continue
+		}
+		station.AddSensor(sensorId)
+		if err := api.stationRegistry.UpdateStation(station); err != nil {
+			log.Print(err)
+		}
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sensor)
+}
@@ -0,0 +1,163 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//geoJsonFeatureCollection is the GeoJSON representation of sensors
+type geoJsonFeatureCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJsonFeature `json:"features"`
+}
+
+type geoJsonFeature struct {
+	Type       string                 `json:"type"`
+	Geometry   *storage.GeoPoint      `json:"geometry"`
+	Properties map[string]interface{} `json:"properties"`
+}
+
+func (api *weatherRestApi) getSensorsNearHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	latitude, errLat := strconv.ParseFloat(r.URL.Query().Get("lat"), 64)
+	longitude, errLon := strconv.ParseFloat(r.URL.Query().Get("lon"), 64)
+	radius, errRadius := strconv.ParseFloat(r.URL.Query().Get("radius"), 64)
+	if errLat != nil || errLon != nil || errRadius != nil || radius <= 0 {
+		http.Error(w, "lat, lon and radius (in meters) are required", http.StatusBadRequest)
+		return
+	}
+
+	scope, err := api.tenantScope(r)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	sensors, err := scope.sensorRegistry.GetSensorsNear(latitude, longitude, radius)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(api.sensorViews(sensors, userId))
+}
+
+func (api *weatherRestApi) getSensorsInBoxHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	box, err := parseBoundingBox(r.URL.Query().Get("bbox"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scope, err := api.tenantScope(r)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	sensors, err := scope.sensorRegistry.GetSensorsInBox(*box)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(api.sensorViews(sensors, userId))
+}
+
+//getSensorsGeoJsonHandler returns the sensors with their latest weather data as GeoJSON FeatureCollection, optionally limited by a bbox
+func (api *weatherRestApi) getSensorsGeoJsonHandler(w http.ResponseWriter, r *http.Request) {
+	scope, err := api.tenantScope(r)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	var sensors []*storage.WeatherSensor
+	if bbox := r.URL.Query().Get("bbox"); len(bbox) != 0 {
+		var box *storage.GeoBox
+		if box, err = parseBoundingBox(bbox); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		sensors, err = scope.sensorRegistry.GetSensorsInBox(*box)
+	} else {
+		sensors, err = scope.sensorRegistry.GetSensors()
+	}
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	sensorIds := make([]uuid.UUID, 0, len(sensors))
+	for _, sensor := range sensors {
+		sensorIds = append(sensorIds, sensor.Id)
+	}
+
+	latestData, err := scope.weatherStorage.GetLatestData(sensorIds)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	latest := make(map[uuid.UUID]map[string]interface{})
+	for _, data := range latestData {
+		latest[data.SensorId] = data.ToMap()
+	}
+
+	collection := geoJsonFeatureCollection{Type: "FeatureCollection", Features: make([]geoJsonFeature, 0)}
+	for _, sensor := range sensors {
+		collection.Features = append(collection.Features, geoJsonFeature{
+			Type:     "Feature",
+			Geometry: storage.NewGeoPoint(sensor.Longitude, sensor.Latitude),
+			Properties: map[string]interface{}{
+				"id":          sensor.Id,
+				"name":        sensor.Name,
+				"location":    sensor.Location,
+				"description": sensor.Description,
+				"elevation":   sensor.Elevation,
+				"indoor":      sensor.Indoor,
+				"tags":        sensor.Tags,
+				"latest":      latest[sensor.Id],
+			},
+		})
+	}
+
+	w.Header().Add("content-type", "application/geo+json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(collection)
+}
+
+//parseBoundingBox parses a bbox in GeoJSON order: minLon,minLat,maxLon,maxLat
+func parseBoundingBox(value string) (*storage.GeoBox, error) {
+	parts := strings.Split(value, ",")
+	if len(parts) != 4 {
+		return nil, errors.New("bbox has to be given as minLon,minLat,maxLon,maxLat")
+	}
+
+	var coordinates [4]float64
+	for i, part := range parts {
+		coordinate, err := strconv.ParseFloat(strings.TrimSpace(part), 64)
+		if err != nil {
+			return nil, err
+		}
+		coordinates[i] = coordinate
+	}
+
+	box := &storage.GeoBox{
+		MinLongitude: coordinates[0],
+		MinLatitude:  coordinates[1],
+		MaxLongitude: coordinates[2],
+		MaxLatitude:  coordinates[3],
+	}
+	return box, box.Validate()
+}
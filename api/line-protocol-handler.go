@@ -0,0 +1,75 @@
+package api
+
+import (
+	"net/http"
+	"regexp"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//influxTokenRegex matches the authorization header of influxdb v2 clients
+var influxTokenRegex = regexp.MustCompile(`^(?i:Token\s+)(.*)$`)
+
+//writeLineProtocolHandler accepts weather data in the influxdb line protocol, compatible with the write api of influxdb v1 and v2
+//the user has to be allowed to add data to all sensors of the request, otherwise nothing is written
+//batches larger than REST_MAX_BODY_SIZE are rejected with 413
+func (api *weatherRestApi) writeLineProtocolHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	precision, exists := storage.LinePrecisions[r.URL.Query().Get("precision")]
+	if !exists {
+		http.Error(w, "invalid precision", http.StatusBadRequest)
+		return
+	}
+
+	body, ok := api.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	dataPoints, err := storage.ParseLineProtocol(body, config.LineProtocolConfiguration.SensorTag, precision, time.Now())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	for _, weatherData := range dataPoints {
+		if !api.canWriteSensor(weatherData.SensorId, userId) {
+			http.Error(w, "", http.StatusNotFound)
+			return
+		}
+	}
+
+	for _, weatherData := range dataPoints {
+		api.NewWeatherData(weatherData)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//canWriteSensor checks if the user is allowed to add weather data to the sensor, unregistered sensors depend on ALLOW_UNREGISTERED_SENSORS
+func (api *weatherRestApi) canWriteSensor(sensorId uuid.UUID, userId string) bool {
+	sensor, err := api.sensorRegistry.GetSensor(sensorId)
+	if err == nil {
+		return api.canManage(sensor, userId)
+	}
+	if !config.AllowUnregisteredSensors {
+		return false
+	}
+	return api.isUnregistered(sensorId)
+}
+
+//influxAuthorization accepts the tokens of influxdb clients as bearer token: the Token scheme of v2 and the password parameter p of v1
+func influxAuthorization(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if matches := influxTokenRegex.FindStringSubmatch(r.Header.Get("Authorization")); matches != nil {
+			r.Header.Set("Authorization", "Bearer "+matches[1])
+		} else if password := r.URL.Query().Get("p"); len(password) != 0 && len(r.Header.Get("Authorization")) == 0 {
+			r.Header.Set("Authorization", "Bearer "+password)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,69 @@
+package api
+
+import (
+	"net/http"
+	"weather-data/config"
+	"weather-data/storage"
+)
+
+//ttnUplinkHandler accepts the uplink messages of a webhook of The Things Stack
+func (api *weatherRestApi) ttnUplinkHandler(w http.ResponseWriter, r *http.Request) {
+	api.addLorawanUplink(w, r, storage.ParseTtnUplink)
+}
+
+//chirpstackUplinkHandler accepts the up events of the http integration of ChirpStack, other events are acknowledged and ignored
+func (api *weatherRestApi) chirpstackUplinkHandler(w http.ResponseWriter, r *http.Request) {
+	if event := r.URL.Query().Get("event"); len(event) != 0 && event != "up" {
+		w.WriteHeader(http.StatusNoContent)
+		return
+	}
+	api.addLorawanUplink(w, r, storage.ParseChirpstackUplink)
+}
+
+//addLorawanUplink maps the DevEUI of the uplink to the sensor, records the link quality and passes the weather data on
+//the payload decoder is taken from the decoder parameter or LORAWAN_PAYLOAD_DECODER
+func (api *weatherRestApi) addLorawanUplink(w http.ResponseWriter, r *http.Request, parse func([]byte) (*storage.LorawanUplink, error)) {
+	body, ok := api.readBody(w, r)
+	if !ok {
+		return
+	}
+
+	uplink, err := parse(body)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	sensor, err := api.sensorRegistry.GetSensorByExternalId(storage.LorawanProtocol, uplink.DevEui)
+	if err != nil || !api.canManage(sensor, r.Header.Get(userIdHeader)) {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	decoder := storage.PayloadDecoder(config.LorawanConfiguration.PayloadDecoder)
+	if value := r.URL.Query().Get("decoder"); len(value) != 0 {
+		decoder = storage.PayloadDecoder(value)
+	}
+
+	valueTypes, err := api.valueTypeCatalog.GetValueTypes()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	weatherData, err := uplink.WeatherData(decoder, valueTypes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	weatherData.SensorId = sensor.Id
+
+	uplink.Link.ReceivedAt = weatherData.TimeStamp
+	api.sensorStatusRegistry.RecordLinkQuality(sensor.Id, uplink.Link)
+
+	if len(weatherData.Values) != 0 {
+		api.NewWeatherData(weatherData)
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,227 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"weather-data/storage"
+
+	"github.com/gorilla/mux"
+)
+
+//getOrganizationsHandler lists the organizations of the user, users with the admin role get all organizations
+func (api *weatherRestApi) getOrganizationsHandler(w http.ResponseWriter, r *http.Request) {
+	var organizations []*storage.Organization
+	var err error
+	if api.isAdmin(r) {
+		organizations, err = api.organizationRegistry.GetOrganizations()
+	} else {
+		organizations, err = api.organizationRegistry.GetOrganizationsOfUser(r.Header.Get(userIdHeader))
+	}
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(organizations)
+}
+
+//createOrganizationHandler creates a new organization, which is reserved to users with the admin role
+//without members the creating user becomes the admin of the organization
+func (api *weatherRestApi) createOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	if !api.isAdmin(r) {
+		http.Error(w, "", http.StatusForbidden)
+		return
+	}
+
+	organization := new(storage.Organization)
+	err := json.NewDecoder(r.Body).Decode(organization)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	if len(organization.Members) == 0 {
+		organization.SetMember(r.Header.Get(userIdHeader), storage.OrganizationAdmin)
+	}
+	if err = organization.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	organization, err = api.organizationRegistry.CreateOrganization(organization)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(organization)
+}
+
+func (api *weatherRestApi) getOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	organization, ok := api.authorizedOrganization(w, r, false)
+	if !ok {
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(organization)
+}
+
+//updateOrganizationHandler changes name and description, the members are managed by the member endpoints
+func (api *weatherRestApi) updateOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	organization, ok := api.authorizedOrganization(w, r, true)
+	if !ok {
+		return
+	}
+	organizationId, members := organization.Id, organization.Members
+
+	err := json.NewDecoder(r.Body).Decode(organization)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	organization.Id = organizationId
+	organization.Members = members
+
+	if err = organization.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = api.organizationRegistry.UpdateOrganization(organization); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(organization)
+}
+
+//deleteOrganizationHandler deletes an organization, which must not own sensors or stations anymore
+func (api *weatherRestApi) deleteOrganizationHandler(w http.ResponseWriter, r *http.Request) {
+	organization, ok := api.authorizedOrganization(w, r, true)
+	if !ok {
+		return
+	}
+
+	sensors, err := api.sensorRegistry.GetSensorsOfOrganization(organization.Id)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	stations, err := api.stationRegistry.GetStationsOfOrganization(organization.Id)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if len(sensors) != 0 || len(stations) != 0 {
+		http.Error(w, "organization still owns sensors or stations", http.StatusConflict)
+		return
+	}
+
+	if err = api.organizationRegistry.DeleteOrganization(organization.Id); err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//setOrganizationMemberHandler adds a user to the organization or changes the role, the body contains the Role
+func (api *weatherRestApi) setOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	organization, ok := api.authorizedOrganization(w, r, true)
+	if !ok {
+		return
+	}
+
+	membership := new(storage.OrganizationMembership)
+	err := json.NewDecoder(r.Body).Decode(membership)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	organization.SetMember(mux.Vars(r)["userId"], membership.Role)
+	if err = organization.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = api.organizationRegistry.UpdateOrganization(organization); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(organization)
+}
+
+//removeOrganizationMemberHandler removes a user from the organization, members are allowed to leave on their own
+func (api *weatherRestApi) removeOrganizationMemberHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+	memberId := mux.Vars(r)["userId"]
+
+	organization, ok := api.authorizedOrganization(w, r, memberId != userId)
+	if !ok {
+		return
+	}
+
+	organization.RemoveMember(memberId)
+	if err := organization.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err := api.organizationRegistry.UpdateOrganization(organization); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(organization)
+}
+
+//getOrganizationSensorsHandler lists the sensors owned by the organization
+func (api *weatherRestApi) getOrganizationSensorsHandler(w http.ResponseWriter, r *http.Request) {
+	organization, ok := api.authorizedOrganization(w, r, false)
+	if !ok {
+		return
+	}
+
+	sensors, err := api.sensorRegistry.GetSensorsOfOrganization(organization.Id)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(api.sensorViews(sensors, r.Header.Get(userIdHeader)))
+}
+
+//getOrganizationStationsHandler lists the stations owned by the organization
+func (api *weatherRestApi) getOrganizationStationsHandler(w http.ResponseWriter, r *http.Request) {
+	organization, ok := api.authorizedOrganization(w, r, false)
+	if !ok {
+		return
+	}
+
+	stations, err := api.stationRegistry.GetStationsOfOrganization(organization.Id)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stations)
+}
@@ -5,8 +5,13 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io/ioutil"
+	"log"
+	"math"
 	"net/http"
 	"regexp"
+	"strconv"
+	"strings"
 	"time"
 	"weather-data/config"
 	"weather-data/storage"
@@ -20,6 +25,14 @@ import (
 
 var userIdHeader = "userid"
 
+//syntheticDemoSensorId is the virtual sensor of the random endpoints without a sensorId
+var syntheticDemoSensorId = uuid.MustParse("5ca1ab1e-0000-4000-8000-000000000001")
+
+//maxSyntheticDataPoints limits the series of the randomlist endpoint
+var maxSyntheticDataPoints = 10000
+
+var userRolesHeader = "userroles"
+
 var bearerTokenRegexPattern = "^(?i:Bearer\\s+)([A-Za-z0-9-_=]+\\.[A-Za-z0-9-_=]+\\.?[A-Za-z0-9-_.+\\/=]*)$"
 
 var bearerTokenRegex *regexp.Regexp = regexp.MustCompile(bearerTokenRegexPattern)
@@ -40,20 +53,47 @@ type UserClaims struct {
 	jwt.StandardClaims
 }
 
+//weatherDataResponse is the envelope of queried weather data
+type weatherDataResponse struct {
+	Units      map[storage.SensorValueType]storage.Unit `json:"units"`
+	Resolution storage.Resolution                       `json:"resolution,omitempty"`
+	Data       []map[string]interface{}                 `json:"data"`
+}
+
 type weatherRestApi struct {
 	weathersource.WeatherSourceBase
-	connection     string
-	config         config.RestConfig
-	weaterStorage  storage.WeatherStorage
-	sensorRegistry storage.SensorRegistry
+	connection           string
+	config               config.RestConfig
+	weaterStorage        storage.WeatherStorage
+	sensorRegistry       storage.SensorRegistry
+	valueTypeCatalog     storage.ValueTypeCatalog
+	stationRegistry      storage.StationRegistry
+	organizationRegistry storage.OrganizationRegistry
+	sensorStatusRegistry storage.SensorStatusRegistry
+	alertRegistry        storage.AlertRegistry
+	webhookRegistry      storage.WebhookRegistry
+	auditRegistry        storage.AuditRegistry
+	sensorPurger         *storage.SensorPurger
+	sourceHealth         weathersource.SourceHealthReporter
+
+	onAlertRuleDeletedFunctions []AlertRuleDeletedFunc
 }
 
 //SetupAPI sets the REST-API up
-func NewRestAPI(connection string, weatherStorage storage.WeatherStorage, sensorRegistry storage.SensorRegistry, config config.RestConfig) *weatherRestApi {
+func NewRestAPI(connection string, weatherStorage storage.WeatherStorage, sensorRegistry storage.SensorRegistry, valueTypeCatalog storage.ValueTypeCatalog, stationRegistry storage.StationRegistry, organizationRegistry storage.OrganizationRegistry, sensorStatusRegistry storage.SensorStatusRegistry, alertRegistry storage.AlertRegistry, webhookRegistry storage.WebhookRegistry, auditRegistry storage.AuditRegistry, sensorPurger *storage.SensorPurger, sourceHealth weathersource.SourceHealthReporter, config config.RestConfig) *weatherRestApi {
 	api := new(weatherRestApi)
 	api.connection = connection
 	api.weaterStorage = weatherStorage
 	api.sensorRegistry = sensorRegistry
+	api.valueTypeCatalog = valueTypeCatalog
+	api.stationRegistry = stationRegistry
+	api.organizationRegistry = organizationRegistry
+	api.sensorStatusRegistry = sensorStatusRegistry
+	api.alertRegistry = alertRegistry
+	api.webhookRegistry = webhookRegistry
+	api.auditRegistry = auditRegistry
+	api.sensorPurger = sensorPurger
+	api.sourceHealth = sourceHealth
 	api.config = config
 	return api
 }
@@ -81,75 +121,317 @@ func (api *weatherRestApi) handleRequests() *mux.Router {
 	router.HandleFunc("/{_dummy:(?i)random}", api.randomWeatherHandler).Methods("GET")
 	router.HandleFunc("/{_dummy:(?i)randomlist}", api.randomWeatherListHandler).Methods("GET")
 
-	//sensor specific stuff
+	//sensor specific stuff, reading public sensors is allowed without token
 	sensorRouter := router.PathPrefix("/{_dummy:(?i)sensor}").Subrouter()
 	sensorRouter.Use(api.UseJwtTokenValidationSecret)
 	sensorRouter.Use(api.UseJwtTokenValidationUrl)
 
 	sensorRouter.HandleFunc("/{id}/{_dummy:(?i)weather-data}", api.getWeatherDataHandler).Methods("GET")
-	sensorRouter.HandleFunc("/{id}/{_dummy:(?i)weather-data}", api.addWeatherDataHandler).Methods("POST")
-
-	sensorRouter.HandleFunc("", api.getAllWeatherSensorHandler).Methods("GET")
-	sensorRouter.HandleFunc("", api.registerWeatherSensorHandler).Methods("POST")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)weather-data}", api.userOnly(api.addWeatherDataHandler)).Methods("POST")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)weather-data}", api.userOnly(api.correctWeatherDataHandler)).Methods("PUT")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)weather-data}", api.userOnly(api.deleteWeatherDataHandler)).Methods("DELETE")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)weather-data}/{_dummy2:(?i)audit}", api.userOnly(api.getWeatherDataAuditHandler)).Methods("GET")
+
+	sensorRouter.Handle("", api.userOnly(api.getAllWeatherSensorHandler)).Methods("GET")
+	sensorRouter.Handle("", api.userOnly(api.registerWeatherSensorHandler)).Methods("POST")
+	sensorRouter.Handle("/{_dummy2:(?i)status}", api.userOnly(api.getSensorStatusOverviewHandler)).Methods("GET")
+	sensorRouter.Handle("/{_dummy2:(?i)alerts}", api.userOnly(api.getOpenAlertsOverviewHandler)).Methods("GET")
+	sensorRouter.Handle("/{_dummy2:(?i)deleted}", api.userOnly(api.getDeletedSensorsHandler)).Methods("GET")
 	sensorRouter.HandleFunc("/{id}", api.getWeatherSensorHandler).Methods("GET")
-	sensorRouter.HandleFunc("/{id}", api.updateWeatherSensorHandler).Methods("PUT")
-	sensorRouter.HandleFunc("/{id}", api.deleteWeatherSensorHandler).Methods("DELETE")
+	sensorRouter.Handle("/{id}", api.userOnly(api.updateWeatherSensorHandler)).Methods("PUT")
+	sensorRouter.Handle("/{id}", api.userOnly(api.deleteWeatherSensorHandler)).Methods("DELETE")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)restore}", api.userOnly(api.restoreWeatherSensorHandler)).Methods("POST")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)calibration}/{_dummy2:(?i)recalculate}", api.userOnly(api.recalculateCalibrationHandler)).Methods("POST")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)shares}/{userId}", api.userOnly(api.shareWeatherSensorHandler)).Methods("PUT")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)shares}/{userId}", api.userOnly(api.unshareWeatherSensorHandler)).Methods("DELETE")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)alert-rules}", api.userOnly(api.getAlertRulesHandler)).Methods("GET")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)alert-rules}", api.userOnly(api.addAlertRuleHandler)).Methods("POST")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)alert-rules}/{ruleId}", api.userOnly(api.getAlertRuleHandler)).Methods("GET")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)alert-rules}/{ruleId}", api.userOnly(api.updateAlertRuleHandler)).Methods("PUT")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)alert-rules}/{ruleId}", api.userOnly(api.deleteAlertRuleHandler)).Methods("DELETE")
+	sensorRouter.Handle("/{id}/{_dummy:(?i)alerts}", api.userOnly(api.getAlertsHandler)).Methods("GET")
+
+	//stations grouping several sensors
+	stationRouter := router.PathPrefix("/{_dummy:(?i)station}").Subrouter()
+	stationRouter.Use(api.UseJwtTokenValidationSecret)
+	stationRouter.Use(api.UseJwtTokenValidationUrl)
+
+	stationRouter.Handle("", api.userOnly(api.getAllStationsHandler)).Methods("GET")
+	stationRouter.Handle("", api.userOnly(api.registerStationHandler)).Methods("POST")
+	stationRouter.HandleFunc("/{id}", api.getStationHandler).Methods("GET")
+	stationRouter.Handle("/{id}", api.userOnly(api.updateStationHandler)).Methods("PUT")
+	stationRouter.Handle("/{id}", api.userOnly(api.deleteStationHandler)).Methods("DELETE")
+	stationRouter.Handle("/{id}/{_dummy:(?i)sensors}/{sensorId}", api.userOnly(api.addStationSensorHandler)).Methods("PUT")
+	stationRouter.Handle("/{id}/{_dummy:(?i)sensors}/{sensorId}", api.userOnly(api.removeStationSensorHandler)).Methods("DELETE")
+	stationRouter.HandleFunc("/{id}/{_dummy:(?i)weather-data}", api.getStationWeatherDataHandler).Methods("GET")
+
+	//organizations jointly owning sensors and stations
+	organizationRouter := router.PathPrefix("/{_dummy:(?i)organization}").Subrouter()
+	organizationRouter.Use(api.UseJwtTokenValidationSecret)
+	organizationRouter.Use(api.UseJwtTokenValidationUrl)
+	organizationRouter.Use(api.RequireAuthentication)
+
+	organizationRouter.HandleFunc("", api.getOrganizationsHandler).Methods("GET")
+	organizationRouter.HandleFunc("", api.createOrganizationHandler).Methods("POST")
+	organizationRouter.HandleFunc("/{id}", api.getOrganizationHandler).Methods("GET")
+	organizationRouter.HandleFunc("/{id}", api.updateOrganizationHandler).Methods("PUT")
+	organizationRouter.HandleFunc("/{id}", api.deleteOrganizationHandler).Methods("DELETE")
+	organizationRouter.HandleFunc("/{id}/{_dummy:(?i)members}/{userId}", api.setOrganizationMemberHandler).Methods("PUT")
+	organizationRouter.HandleFunc("/{id}/{_dummy:(?i)members}/{userId}", api.removeOrganizationMemberHandler).Methods("DELETE")
+	organizationRouter.HandleFunc("/{id}/{_dummy:(?i)sensors}", api.getOrganizationSensorsHandler).Methods("GET")
+	organizationRouter.HandleFunc("/{id}/{_dummy:(?i)stations}", api.getOrganizationStationsHandler).Methods("GET")
+
+	//webhook subscriptions pushing new weather data
+	webhookRouter := router.PathPrefix("/{_dummy:(?i)webhooks}").Subrouter()
+	webhookRouter.Use(api.UseJwtTokenValidationSecret)
+	webhookRouter.Use(api.UseJwtTokenValidationUrl)
+	webhookRouter.Use(api.RequireAuthentication)
+
+	webhookRouter.HandleFunc("", api.getWebhooksHandler).Methods("GET")
+	webhookRouter.HandleFunc("", api.addWebhookHandler).Methods("POST")
+	webhookRouter.HandleFunc("/{id}", api.getWebhookHandler).Methods("GET")
+	webhookRouter.HandleFunc("/{id}", api.updateWebhookHandler).Methods("PUT")
+	webhookRouter.HandleFunc("/{id}", api.deleteWebhookHandler).Methods("DELETE")
+	webhookRouter.HandleFunc("/{id}/{_dummy:(?i)deliveries}", api.getWebhookDeliveriesHandler).Methods("GET")
+
+	//uploads of weather stations using the weather underground or ecowitt protocol
+	router.HandleFunc("/{_dummy:(?i)weatherstation}/{_dummy2:(?i)updateweatherstation.php}", api.wundergroundUploadHandler).Methods("GET")
+	router.HandleFunc("/{_dummy:(?i)data}/{_dummy2:(?i)report}", api.ecowittUploadHandler).Methods("POST")
+	router.HandleFunc("/{_dummy:(?i)data}/{_dummy2:(?i)report}/", api.ecowittUploadHandler).Methods("POST")
+
+	//influxdb line protocol, the write endpoints of influxdb v1 and v2
+	writeHandler := influxAuthorization(api.authenticated(api.userOnly(api.writeLineProtocolHandler)))
+	router.Handle("/{_dummy:(?i)write}", writeHandler).Methods("POST")
+	router.Handle("/{_dummy:(?i)api}/{_dummy2:(?i)v2}/{_dummy3:(?i)write}", writeHandler).Methods("POST")
+
+	//uplink webhooks of LoRaWAN network servers
+	router.Handle("/{_dummy:(?i)lorawan}/{_dummy2:(?i)ttn}", api.authenticated(api.userOnly(api.ttnUplinkHandler))).Methods("POST")
+	router.Handle("/{_dummy:(?i)lorawan}/{_dummy2:(?i)chirpstack}", api.authenticated(api.userOnly(api.chirpstackUplinkHandler))).Methods("POST")
+
+	//health of the configured weather sources
+	router.Handle("/{_dummy:(?i)sources}", api.adminOnly(api.getSourcesHandler)).Methods("GET")
+
+	//geospatial sensor search, anonymous requests only find public sensors
+	sensorsRouter := router.PathPrefix("/{_dummy:(?i)sensors}").Subrouter()
+	sensorsRouter.Use(api.UseJwtTokenValidationSecret)
+	sensorsRouter.Use(api.UseJwtTokenValidationUrl)
+
+	sensorsRouter.HandleFunc("/{_dummy:(?i)near}", api.getSensorsNearHandler).Methods("GET")
+	sensorsRouter.HandleFunc("/{_dummy:(?i)within}", api.getSensorsInBoxHandler).Methods("GET")
+	sensorsRouter.HandleFunc("/{_dummy:(?i)geojson}", api.getSensorsGeoJsonHandler).Methods("GET")
+
+	//value type catalog, reading is public as it documents the available value types
+	router.HandleFunc("/{_dummy:(?i)value-types}", api.getValueTypesHandler).Methods("GET")
+	router.HandleFunc("/{_dummy:(?i)value-types}/{name}", api.getValueTypeHandler).Methods("GET")
+	router.Handle("/{_dummy:(?i)value-types}", api.adminOnly(api.addValueTypeHandler)).Methods("POST")
+	router.Handle("/{_dummy:(?i)value-types}/{name}", api.adminOnly(api.updateValueTypeHandler)).Methods("PUT")
+	router.Handle("/{_dummy:(?i)value-types}/{name}", api.adminOnly(api.deleteValueTypeHandler)).Methods("DELETE")
 
 	return router
 }
 
+//randomWeatherHandler returns synthetic weather data of a virtual sensor at a time, by default now
 func (api *weatherRestApi) randomWeatherHandler(w http.ResponseWriter, r *http.Request) {
+	weather, ok := syntheticWeather(w, r)
+	if !ok {
+		return
+	}
+
+	timeStamp := time.Now().Truncate(time.Second)
+	if value := r.URL.Query().Get("time"); len(value) != 0 {
+		var err error
+		if timeStamp, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
 	w.Header().Add("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(storage.NewRandomWeatherData().ToMap())
+	json.NewEncoder(w).Encode(weather.At(timeStamp).ToMap())
 }
 
+//randomWeatherListHandler returns a series of synthetic weather data of a virtual sensor
+//without start and end the last 10 intervals up to now are returned, the series is aligned to the interval
 func (api *weatherRestApi) randomWeatherListHandler(w http.ResponseWriter, r *http.Request) {
-	var datapoints = make([]*storage.WeatherData, 0)
+	weather, ok := syntheticWeather(w, r)
+	if !ok {
+		return
+	}
 
-	for i := 0; i < 10; i++ {
-		datapoints = append(datapoints, storage.NewRandomWeatherData())
+	var err error
+	interval := time.Minute
+	if value := r.URL.Query().Get("interval"); len(value) != 0 {
+		if interval, err = time.ParseDuration(value); err != nil || interval <= 0 {
+			http.Error(w, "invalid interval", http.StatusBadRequest)
+			return
+		}
+	}
+
+	end := time.Now().Truncate(interval)
+	if value := r.URL.Query().Get("end"); len(value) != 0 {
+		if end, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	start := end.Add(-9 * interval)
+	if value := r.URL.Query().Get("start"); len(value) != 0 {
+		if start, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	if end.Before(start) || int(end.Sub(start)/interval) >= maxSyntheticDataPoints {
+		http.Error(w, fmt.Sprintf("the series is limited to %v datapoints", maxSyntheticDataPoints), http.StatusBadRequest)
+		return
 	}
 
 	w.Header().Add("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(storage.ToMap(datapoints))
+	json.NewEncoder(w).Encode(storage.ToMap(weather.Series(start, end, interval)))
+}
+
+//syntheticWeather returns the generator of the sensorId, lat and lon parameters
+//without a sensorId the same demo sensor is used for every request
+func syntheticWeather(w http.ResponseWriter, r *http.Request) (*storage.SyntheticWeather, bool) {
+	query := r.URL.Query()
+
+	sensorId := syntheticDemoSensorId
+	if value := query.Get("sensorId"); len(value) != 0 {
+		var err error
+		if sensorId, err = uuid.Parse(value); err != nil {
+			http.Error(w, "invalid sensorId", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	latitude, longitude := storage.DefaultSyntheticLatitude, storage.DefaultSyntheticLongitude
+	if value := query.Get("lat"); len(value) != 0 {
+		var err error
+		if latitude, err = strconv.ParseFloat(value, 64); err != nil || math.Abs(latitude) > 90 {
+			http.Error(w, "invalid lat", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+	if value := query.Get("lon"); len(value) != 0 {
+		var err error
+		if longitude, err = strconv.ParseFloat(value, 64); err != nil || math.Abs(longitude) > 180 {
+			http.Error(w, "invalid lon", http.StatusBadRequest)
+			return nil, false
+		}
+	}
+
+	return storage.NewSyntheticWeather(sensorId, latitude, longitude), true
 }
 
 func (api *weatherRestApi) getWeatherDataHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+	//the sensor is nil for unregistered sensors, it is needed for its timezone and derived values depending on the sensor metadata
+	sensorid, sensor, ok := api.weatherDataSensor(w, r, api.canRead)
+	if !ok {
+		return
+	}
 
-	query, err := storage.ParseWeatherQuery(r.URL.Query())
+	api.writeWeatherData(w, r, []uuid.UUID{sensorid}, []*storage.WeatherSensor{sensor}, sensor.TimeLocation(), nil)
+}
+
+//writeWeatherData queries the weather data of the sensors and writes the weatherDataResponse
+//merge combines the datapoints of several sensors before derived values are computed, it may be nil
+func (api *weatherRestApi) writeWeatherData(w http.ResponseWriter, r *http.Request, sensorIds []uuid.UUID, sensors []*storage.WeatherSensor, location *time.Location, merge func([]*storage.WeatherData, *storage.WeatherQuery) []*storage.WeatherData) {
+	valueTypes, err := api.valueTypeCatalog.GetValueTypes()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	query, err := storage.ParseWeatherQuery(r.URL.Query(), storage.ValueTypeNames(valueTypes), location)
 	if err != nil {
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
 
-	sensorid, err := uuid.Parse(id)
-	query.SensorIds = append(query.SensorIds, sensorid)
+	query.SensorIds = append(query.SensorIds, sensorIds...)
+	if query.Resolution == storage.AutoResolution {
+		query.Resolution = chooseResolution(query, sensors)
+	}
 
+	scope, err := api.tenantScope(r)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	data, err := scope.weatherStorage.GetData(query.WithDerivedValueDependencies())
 	if err != nil {
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
 
-	data, err := api.weaterStorage.GetData(query)
+	if merge != nil {
+		data = merge(data, query)
+	}
+
+	data = storage.AddDerivedValues(data, query, sensors)
+	data = storage.GetOnlyQueriedFields(data, query)
+
+	units, err := storage.ConvertUnits(data, query, valueTypes)
 	if err != nil {
-		http.Error(w, "", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	res := storage.ToMap(storage.GetOnlyQueriedFields(data, query))
+	res := weatherDataResponse{
+		Units:      units,
+		Resolution: query.Resolution,
+		Data:       storage.ToMap(storage.RoundValues(data, valueTypes, units)),
+	}
 
 	w.Header().Add("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
 }
 
+//readBody reads the request body up to REST_MAX_BODY_SIZE, larger bodies are rejected with 413
+func (api *weatherRestApi) readBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	body, err := ioutil.ReadAll(http.MaxBytesReader(w, r.Body, api.config.MaxBodySize))
+	if err != nil && int64(len(body)) >= api.config.MaxBodySize {
+		http.Error(w, "request body too large", http.StatusRequestEntityTooLarge)
+		return nil, false
+	}
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return nil, false
+	}
+	return body, true
+}
+
+//chooseResolution returns the resolution of the range of the query, limited by the strictest retention of the sensors
+//without the retention job only datapoints are stored
+func chooseResolution(query *storage.WeatherQuery, sensors []*storage.WeatherSensor) storage.Resolution {
+	if !config.RetentionConfiguration.Enabled {
+		return storage.NoAggregation
+	}
+	return storage.ChooseResolution(query.Start, query.End, time.Now(), combinedRetention(sensors))
+}
+
+//combinedRetention returns the strictest retention of the sensors, the default retention if there are none
+func combinedRetention(sensors []*storage.WeatherSensor) storage.RetentionPolicy {
+	defaultPolicy := storage.DefaultRetentionPolicy(config.RetentionConfiguration)
+	if len(sensors) == 0 {
+		return defaultPolicy
+	}
+	policy := sensors[0].RetentionPolicy(defaultPolicy)
+	for _, sensor := range sensors[1:] {
+		policy = policy.Stricter(sensor.RetentionPolicy(defaultPolicy))
+	}
+	return policy
+}
+
 func (api *weatherRestApi) addWeatherDataHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+	sensorId, _, ok := api.weatherDataSensor(w, r, api.canManage)
+	if !ok {
+		return
+	}
 
 	var data = make(map[string]interface{})
 	err := json.NewDecoder(r.Body).Decode(&data)
@@ -158,7 +440,7 @@ func (api *weatherRestApi) addWeatherDataHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
-	data[storage.SensorId] = id
+	data[storage.SensorId] = sensorId
 	if _, containsTimeStamp := data[storage.TimeStamp]; !containsTimeStamp {
 		data[storage.TimeStamp] = time.Now()
 	}
@@ -169,6 +451,17 @@ func (api *weatherRestApi) addWeatherDataHandler(w http.ResponseWriter, r *http.
 		return
 	}
 
+	valueTypes, err := api.valueTypeCatalog.GetValueTypes()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	if err = storage.ValidateWeatherData(weatherData, valueTypes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
 	api.NewWeatherData(weatherData)
 
 	w.Header().Add("content-type", "application/json")
@@ -180,7 +473,6 @@ func (api *weatherRestApi) registerWeatherSensorHandler(w http.ResponseWriter, r
 	var err error
 
 	sensor := new(storage.WeatherSensor)
-	sensor.UserId = r.Header.Get(userIdHeader)
 
 	err = json.NewDecoder(r.Body).Decode(sensor)
 	if err != nil {
@@ -188,6 +480,23 @@ func (api *weatherRestApi) registerWeatherSensorHandler(w http.ResponseWriter, r
 		return
 	}
 
+	//the sensor is owned by the user or by the given organization
+	var ok bool
+	if sensor.UserId, ok = api.assignOwner(w, sensor.OrganizationId, r.Header.Get(userIdHeader)); !ok {
+		return
+	}
+
+	sensor.Deletion = nil
+	sensor.NormalizeExternalIds()
+	if err = sensor.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if !api.availableExternalIds(w, sensor) {
+		return
+	}
+
 	sensor, err = api.sensorRegistry.RegisterSensor(sensor)
 	if err != nil {
 		http.Error(w, "", http.StatusBadRequest)
@@ -202,7 +511,8 @@ func (api *weatherRestApi) registerWeatherSensorHandler(w http.ResponseWriter, r
 func (api *weatherRestApi) getAllWeatherSensorHandler(w http.ResponseWriter, r *http.Request) {
 	userId := r.Header.Get(userIdHeader)
 
-	weatherSensors, err := api.sensorRegistry.GetSensorsOfUser(userId)
+	//the sensors of the user and of the organizations of the user
+	weatherSensors, err := api.tenantSensors(userId)
 
 	if err != nil {
 		http.Error(w, "", http.StatusNotFound)
@@ -211,53 +521,60 @@ func (api *weatherRestApi) getAllWeatherSensorHandler(w http.ResponseWriter, r *
 
 	w.Header().Add("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(weatherSensors)
+	json.NewEncoder(w).Encode(api.sensorViews(weatherSensors, userId))
 }
 
 func (api *weatherRestApi) getWeatherSensorHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
-
-	sensorId, err := uuid.Parse(id)
-	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+	weatherSensor, ok := api.readableSensor(w, r)
+	if !ok {
 		return
 	}
 
-	weatherSensor, err := api.sensorRegistry.GetSensor(sensorId)
-	if err != nil {
-		http.Error(w, "", http.StatusNotFound)
-		return
-	}
+	//sensors without data have no status yet
+	status, _ := api.sensorStatusRegistry.GetStatus(weatherSensor.Id)
 
 	w.Header().Add("content-type", "application/json")
 	w.WriteHeader(http.StatusOK)
-	json.NewEncoder(w).Encode(weatherSensor)
+	//users who can not manage the sensor get the read view without owner, sharing and ingest settings
+	json.NewEncoder(w).Encode(api.sensorStatusView(weatherSensor, status, time.Now(), r.Header.Get(userIdHeader)))
 }
 
 func (api *weatherRestApi) updateWeatherSensorHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+	sensorId, userId, organizationId, externalIds, createdAt := sensor.Id, sensor.UserId, sensor.OrganizationId, sensor.ExternalIds, sensor.CreatedAt
+	sensor.ExternalIds = nil
 
-	sensorId, err := uuid.Parse(id)
+	err := json.NewDecoder(r.Body).Decode(sensor)
 	if err != nil {
 		http.Error(w, "", http.StatusBadRequest)
 		return
 	}
 
-	sensor, err := api.sensorRegistry.GetSensor(sensorId)
-	if err != nil {
-		http.Error(w, "", http.StatusNotFound)
+	sensor.Id = sensorId
+	sensor.CreatedAt = createdAt
+	sensor.Deletion = nil
+	if sensor.ExternalIds == nil {
+		sensor.ExternalIds = externalIds
+	}
+	sensor.NormalizeExternalIds()
+	//passwords and secret ids are not returned, so they are kept if an external id is sent without them
+	sensor.KeepExternalIdSecrets(externalIds)
+	//changing the organization moves the sensor to the new organization or back to the user
+	if sensor.UserId, sensor.OrganizationId, ok = api.transferOwner(w, userId, organizationId, sensor.OrganizationId, r.Header.Get(userIdHeader)); !ok {
 		return
 	}
 
-	err = json.NewDecoder(r.Body).Decode(sensor)
-	if err != nil {
-		http.Error(w, "", http.StatusBadRequest)
+	if err = sensor.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	sensor.Id = sensorId
+	if !api.availableExternalIds(w, sensor) {
+		return
+	}
 
 	err = api.sensorRegistry.UpdateSensor(sensor)
 	if err != nil {
@@ -271,24 +588,140 @@ func (api *weatherRestApi) updateWeatherSensorHandler(w http.ResponseWriter, r *
 }
 
 func (api *weatherRestApi) deleteWeatherSensorHandler(w http.ResponseWriter, r *http.Request) {
-	vars := mux.Vars(r)
-	id := vars["id"]
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
 
-	sensorId, err := uuid.Parse(id)
+	//the policy for the weather data is carried out when the sensor is purged after the restore window
+	defaultPolicy, _ := storage.ParseDeletionPolicy(config.DeletionConfiguration.Policy, storage.KeepData)
+	policy, err := storage.ParseDeletionPolicy(r.URL.Query().Get("policy"), defaultPolicy)
 	if err != nil {
-		http.Error(w, "", http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusBadRequest)
 		return
 	}
 
-	err = api.sensorRegistry.DeleteSensor(sensorId)
+	stations, err := api.stationRegistry.GetStationsOfSensor(sensor.Id)
 	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	sensor.Deletion = storage.NewSensorDeletion(policy, r.Header.Get(userIdHeader), config.DeletionConfiguration.RestoreWindow)
+	for _, station := range stations {
+		sensor.Deletion.StationIds = append(sensor.Deletion.StationIds, station.Id)
+	}
+	if err := api.sensorRegistry.SoftDeleteSensor(sensor); err != nil {
 		http.Error(w, "", http.StatusNotFound)
 		return
 	}
 
+	//a deleted sensor is no member of stations, the purger removes remaining memberships
+	for _, station := range stations {
+		station.RemoveSensor(sensor.Id)
+		if err := api.stationRegistry.UpdateStation(station); err != nil {
+			log.Print(err)
+		}
+	}
+
+	//without restore window the sensor is purged in the background, archiving its weather data may take a while
+	if config.DeletionConfiguration.RestoreWindow == 0 {
+		api.sensorPurger.Trigger()
+	}
+
 	w.WriteHeader(http.StatusNoContent)
 }
 
+//recalculateCalibrationHandler re-applies the current calibration to the stored data, optionally limited by start and end
+func (api *weatherRestApi) recalculateCalibrationHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	var err error
+	start := time.Unix(0, 0)
+	end := time.Now()
+	if value := r.URL.Query().Get("start"); len(value) != 0 {
+		if start, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+	if value := r.URL.Query().Get("end"); len(value) != 0 {
+		if end, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+	}
+
+	scope, err := api.tenantScope(r)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	updated, err := storage.RecalculateCalibration(scope.weatherStorage, sensor, start, end)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(map[string]int{"recalculated": updated})
+}
+
+//shareWeatherSensorHandler grants a user read access to a shared sensor
+func (api *weatherRestApi) shareWeatherSensorHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	userId := mux.Vars(r)["userId"]
+	if !sensor.IsSharedWith(userId) {
+		sensor.SharedWith = append(sensor.SharedWith, userId)
+	}
+	if sensor.Visibility != storage.Public {
+		sensor.Visibility = storage.Shared
+	}
+
+	if err := api.sensorRegistry.UpdateSensor(sensor); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sensor)
+}
+
+//unshareWeatherSensorHandler revokes the read access of a user
+func (api *weatherRestApi) unshareWeatherSensorHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	userId := mux.Vars(r)["userId"]
+	sharedWith := make([]string, 0)
+	for _, sharedUserId := range sensor.SharedWith {
+		if sharedUserId != userId {
+			sharedWith = append(sharedWith, sharedUserId)
+		}
+	}
+	sensor.SharedWith = sharedWith
+
+	if err := api.sensorRegistry.UpdateSensor(sensor); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(sensor)
+}
+
 func (api *weatherRestApi) homePageHandler(w http.ResponseWriter, r *http.Request) {
 	fmt.Fprintf(w, "Welcome to the Weather API!")
 }
@@ -299,6 +732,10 @@ func (api *weatherRestApi) UseJwtTokenValidationUrl(next http.Handler) http.Hand
 			next.ServeHTTP(w, r)
 			return
 		}
+		if isAnonymous(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		req, err := http.NewRequest(http.MethodGet, api.config.JwtTokenValidationUrl, &bytes.Buffer{})
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusBadRequest)
@@ -325,6 +762,7 @@ func (api *weatherRestApi) UseJwtTokenValidationUrl(next http.Handler) http.Hand
 			return
 		}
 		r.Header.Set(userIdHeader, validation.Identity.Uid)
+		r.Header.Set(userRolesHeader, strings.Join(validation.Identity.Roles, ","))
 		next.ServeHTTP(w, r)
 	})
 }
@@ -335,23 +773,79 @@ func (api *weatherRestApi) UseJwtTokenValidationSecret(next http.Handler) http.H
 			next.ServeHTTP(w, r)
 			return
 		}
+		if isAnonymous(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
 		claims, err := api.parseToken(r.Header)
 		if err != nil {
 			http.Error(w, err.Error(), http.StatusUnauthorized)
 			return
 		}
 		r.Header.Set(userIdHeader, claims.Uid)
+		r.Header.Set(userRolesHeader, strings.Join(claims.Roles, ","))
+		next.ServeHTTP(w, r)
+	})
+}
+
+//isAnonymous checks if the request has no authorization token, the user headers of anonymous requests are removed
+func isAnonymous(r *http.Request) bool {
+	if _, exists := r.Header["Authorization"]; exists {
+		return false
+	}
+	r.Header.Del(userIdHeader)
+	r.Header.Del(userRolesHeader)
+	return true
+}
+
+//RequireAuthentication rejects all anonymous requests
+func (api *weatherRestApi) RequireAuthentication(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if len(r.Header.Get(userIdHeader)) == 0 {
+			http.Error(w, "missing authorization token", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+//RequireAdminRole rejects all requests of users without the configured admin role
+func (api *weatherRestApi) RequireAdminRole(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !api.isAdmin(r) {
+			http.Error(w, "", http.StatusForbidden)
+			return
+		}
 		next.ServeHTTP(w, r)
 	})
 }
 
+//authenticated wraps the handler with the configured jwt-token validations
+func (api *weatherRestApi) authenticated(handler http.Handler) http.Handler {
+	return api.UseJwtTokenValidationSecret(api.UseJwtTokenValidationUrl(handler))
+}
+
+//adminOnly wraps the handler with the jwt-token validations and the admin role check
+func (api *weatherRestApi) adminOnly(handler http.HandlerFunc) http.Handler {
+	return api.authenticated(api.RequireAuthentication(api.RequireAdminRole(handler)))
+}
+
+//userOnly rejects anonymous requests to the handler, the jwt-token validations are done by the router
+func (api *weatherRestApi) userOnly(handler http.HandlerFunc) http.Handler {
+	return api.RequireAuthentication(handler)
+}
+
 func (api *weatherRestApi) parseToken(header http.Header) (*UserClaims, error) {
 	authorizationHeader, exists := header["Authorization"]
 	if !exists {
 		return nil, errors.New("missing authorization token")
 	}
 
-	jwtFromHeader := bearerTokenRegex.FindStringSubmatch(authorizationHeader[0])[1]
+	matches := bearerTokenRegex.FindStringSubmatch(authorizationHeader[0])
+	if matches == nil {
+		return nil, errors.New("invalid authorization token")
+	}
+	jwtFromHeader := matches[1]
 
 	claims := new(UserClaims)
 
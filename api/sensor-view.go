@@ -0,0 +1,84 @@
+package api
+
+import (
+	"time"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//publicSensor is the read view of a sensor for users who can not manage it
+//the owner, sharing, ingest ids, calibrations, alert settings and retention are left out
+type publicSensor struct {
+	Name                      string
+	Id                        uuid.UUID
+	Location                  string
+	Longitude                 float64
+	Latitude                  float64
+	Elevation                 *float64
+	MountingHeight            *float64
+	Indoor                    bool
+	HardwareModel             string
+	FirmwareVersion           string
+	Timezone                  string
+	Tags                      []string
+	Description               string
+	ExpectedReportingInterval int
+	Visibility                storage.SensorVisibility
+}
+
+func newPublicSensor(sensor *storage.WeatherSensor) *publicSensor {
+	return &publicSensor{
+		Name:                      sensor.Name,
+		Id:                        sensor.Id,
+		Location:                  sensor.Location,
+		Longitude:                 sensor.Longitude,
+		Latitude:                  sensor.Latitude,
+		Elevation:                 sensor.Elevation,
+		MountingHeight:            sensor.MountingHeight,
+		Indoor:                    sensor.Indoor,
+		HardwareModel:             sensor.HardwareModel,
+		FirmwareVersion:           sensor.FirmwareVersion,
+		Timezone:                  sensor.Timezone,
+		Tags:                      sensor.Tags,
+		Description:               sensor.Description,
+		ExpectedReportingInterval: sensor.ExpectedReportingInterval,
+		Visibility:                sensor.Visibility,
+	}
+}
+
+//publicSensorResponse is the read view of a sensor together with its liveness status
+type publicSensorResponse struct {
+	*publicSensor
+	Status *storage.SensorStatus
+}
+
+//sensorView returns the whole sensor to users who can manage it, the read view to all others
+func (api *weatherRestApi) sensorView(sensor *storage.WeatherSensor, userId string) interface{} {
+	if api.canManage(sensor, userId) {
+		return sensor
+	}
+	return newPublicSensor(sensor)
+}
+
+//sensorViews returns the sensorView of each sensor
+func (api *weatherRestApi) sensorViews(sensors []*storage.WeatherSensor, userId string) []interface{} {
+	result := make([]interface{}, 0, len(sensors))
+	for _, sensor := range sensors {
+		result = append(result, api.sensorView(sensor, userId))
+	}
+	return result
+}
+
+//sensorResponseView returns the sensorResponse to users who can manage the sensor, the read view with the status to all others
+func (api *weatherRestApi) sensorResponseView(res sensorResponse, userId string) interface{} {
+	if api.canManage(res.WeatherSensor, userId) {
+		return res
+	}
+	return publicSensorResponse{publicSensor: newPublicSensor(res.WeatherSensor), Status: res.Status}
+}
+
+//sensorStatusView returns the sensorResponseView of the sensor with its status at the time
+func (api *weatherRestApi) sensorStatusView(sensor *storage.WeatherSensor, status *storage.SensorStatus, now time.Time, userId string) interface{} {
+	return api.sensorResponseView(newSensorResponse(sensor, status, now), userId)
+}
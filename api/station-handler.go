@@ -0,0 +1,216 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+func (api *weatherRestApi) getAllStationsHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	stations, err := api.stationRegistry.GetStationsOfUser(userId)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(stations)
+}
+
+func (api *weatherRestApi) registerStationHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	station := new(storage.Station)
+	err := json.NewDecoder(r.Body).Decode(station)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	//the station is owned by the user or by the given organization
+	var ok bool
+	if station.UserId, ok = api.assignOwner(w, station.OrganizationId, userId); !ok {
+		return
+	}
+	if err = station.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	//members have to be added one by one, as the access to each sensor is checked
+	station.SensorIds = make([]uuid.UUID, 0)
+
+	station, err = api.stationRegistry.RegisterStation(station)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(station)
+}
+
+func (api *weatherRestApi) getStationHandler(w http.ResponseWriter, r *http.Request) {
+	station, ok := api.authorizedStation(w, r, api.canReadStation)
+	if !ok {
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(station)
+}
+
+func (api *weatherRestApi) updateStationHandler(w http.ResponseWriter, r *http.Request) {
+	station, ok := api.authorizedStation(w, r, api.canManageStation)
+	if !ok {
+		return
+	}
+	stationId, userId, organizationId, sensorIds := station.Id, station.UserId, station.OrganizationId, station.SensorIds
+
+	err := json.NewDecoder(r.Body).Decode(station)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	station.Id = stationId
+	station.SensorIds = sensorIds
+	//changing the organization moves the station to the new organization or back to the user
+	if station.UserId, station.OrganizationId, ok = api.transferOwner(w, userId, organizationId, station.OrganizationId, r.Header.Get(userIdHeader)); !ok {
+		return
+	}
+
+	if err = station.Validate(); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = api.stationRegistry.UpdateStation(station); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(station)
+}
+
+func (api *weatherRestApi) deleteStationHandler(w http.ResponseWriter, r *http.Request) {
+	station, ok := api.authorizedStation(w, r, api.canManageStation)
+	if !ok {
+		return
+	}
+
+	if err := api.stationRegistry.DeleteStation(station.Id); err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//addStationSensorHandler adds a sensor managed by the user to the station
+func (api *weatherRestApi) addStationSensorHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	station, ok := api.authorizedStation(w, r, api.canManageStation)
+	if !ok {
+		return
+	}
+
+	sensorId, err := uuid.Parse(mux.Vars(r)["sensorId"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	sensor, err := api.sensorRegistry.GetSensor(sensorId)
+	if err != nil || !api.canManage(sensor, userId) {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	station.AddSensor(sensorId)
+	if err = api.stationRegistry.UpdateStation(station); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(station)
+}
+
+func (api *weatherRestApi) removeStationSensorHandler(w http.ResponseWriter, r *http.Request) {
+	station, ok := api.authorizedStation(w, r, api.canManageStation)
+	if !ok {
+		return
+	}
+
+	sensorId, err := uuid.Parse(mux.Vars(r)["sensorId"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	station.RemoveSensor(sensorId)
+	if err = api.stationRegistry.UpdateStation(station); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(station)
+}
+
+//getStationWeatherDataHandler merges the weather data of all member sensors readable by the user to one timeline
+//datapoints are combined within the interval given by the interval parameter (default 1m)
+func (api *weatherRestApi) getStationWeatherDataHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	station, ok := api.authorizedStation(w, r, api.canReadStation)
+	if !ok {
+		return
+	}
+
+	//the merged timeline gets the id of the station, sensor metadata needed by derived values is taken from the members
+	stationSensor := &storage.WeatherSensor{Id: station.Id, Name: station.Name, Timezone: station.Timezone}
+	sensorIds := make([]uuid.UUID, 0)
+	members := make([]*storage.WeatherSensor, 0)
+	for _, sensorId := range station.SensorIds {
+		sensor, err := api.sensorRegistry.GetSensor(sensorId)
+		if err != nil || !api.canRead(sensor, userId) {
+			continue
+		}
+		sensorIds = append(sensorIds, sensorId)
+		members = append(members, sensor)
+		if stationSensor.Elevation == nil {
+			stationSensor.Elevation = sensor.Elevation
+		}
+	}
+
+	if len(sensorIds) == 0 {
+		w.Header().Add("content-type", "application/json")
+		w.WriteHeader(http.StatusOK)
+		json.NewEncoder(w).Encode(weatherDataResponse{Units: map[storage.SensorValueType]storage.Unit{}, Data: []map[string]interface{}{}})
+		return
+	}
+
+	//the resolution is chosen by the strictest retention of the members
+	retention := combinedRetention(members)
+	stationSensor.Retention = &retention
+
+	merge := func(data []*storage.WeatherData, query *storage.WeatherQuery) []*storage.WeatherData {
+		return storage.MergeWeatherData(data, station, query.Interval)
+	}
+	api.writeWeatherData(w, r, sensorIds, []*storage.WeatherSensor{stationSensor}, station.TimeLocation(), merge)
+}
@@ -0,0 +1,83 @@
+package api
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"net/url"
+	"weather-data/storage"
+)
+
+//wundergroundUploadHandler accepts the GET uploads of the weather underground protocol, the station is identified by ID and PASSWORD
+func (api *weatherRestApi) wundergroundUploadHandler(w http.ResponseWriter, r *http.Request) {
+	fields := r.URL.Query()
+
+	sensor, err := api.sensorRegistry.GetSensorByExternalId(storage.WundergroundProtocol, fields.Get("ID"))
+	//the password is compared in constant time to not leak it by the response time
+	if err != nil || subtle.ConstantTimeCompare([]byte(sensor.ExternalId(storage.WundergroundProtocol).Password), []byte(fields.Get("PASSWORD"))) != 1 {
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+
+	if !api.addStationUpload(w, sensor, fields) {
+		return
+	}
+
+	w.Header().Add("content-type", "text/plain")
+	w.WriteHeader(http.StatusOK)
+	w.Write([]byte("success\n"))
+}
+
+//ecowittUploadHandler accepts the form POST uploads of the ecowitt protocol, the station is identified by its PASSKEY
+func (api *weatherRestApi) ecowittUploadHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	sensor, err := api.sensorRegistry.GetSensorByExternalId(storage.EcowittProtocol, r.PostForm.Get("PASSKEY"))
+	if err != nil {
+		http.Error(w, "", http.StatusUnauthorized)
+		return
+	}
+
+	if !api.addStationUpload(w, sensor, r.PostForm) {
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+//addStationUpload converts the upload to WeatherData of the sensor and passes it on
+func (api *weatherRestApi) addStationUpload(w http.ResponseWriter, sensor *storage.WeatherSensor, fields url.Values) bool {
+	valueTypes, err := api.valueTypeCatalog.GetValueTypes()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return false
+	}
+
+	weatherData, err := storage.FromStationUpload(sensor.Id, fields, valueTypes)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return false
+	}
+
+	api.NewWeatherData(weatherData)
+	return true
+}
+
+//availableExternalIds checks that the external ids of the sensor are not used by another sensor
+//secret ids are rejected with a generic error, so the response does not reveal that the secret exists
+func (api *weatherRestApi) availableExternalIds(w http.ResponseWriter, sensor *storage.WeatherSensor) bool {
+	for _, externalId := range sensor.ExternalIds {
+		other, err := api.sensorRegistry.GetSensorByExternalId(externalId.Protocol, externalId.Id)
+		if err == nil && other.Id != sensor.Id && externalId.IsSecret() {
+			http.Error(w, "", http.StatusBadRequest)
+			return false
+		}
+		if err == nil && other.Id != sensor.Id {
+			http.Error(w, "external id is already in use", http.StatusConflict)
+			return false
+		}
+	}
+	return true
+}
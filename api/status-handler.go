@@ -0,0 +1,86 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//sensorResponse is a sensor together with its liveness status
+type sensorResponse struct {
+	*storage.WeatherSensor
+	Status *storage.SensorStatus
+}
+
+//newSensorResponse derives the state of the status, sensors without data get an unknown status
+func newSensorResponse(sensor *storage.WeatherSensor, status *storage.SensorStatus, now time.Time) sensorResponse {
+	if status == nil {
+		status = storage.NewSensorStatus(sensor.Id)
+	}
+	status.State = status.StateAt(now, sensor.ReportingInterval())
+	return sensorResponse{WeatherSensor: sensor, Status: status}
+}
+
+//getSensorStatusOverviewHandler lists the status of the sensors of the user and of the organizations of the user
+//the list can be limited to a state with the state parameter, e.g. state=offline
+func (api *weatherRestApi) getSensorStatusOverviewHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+	state := storage.SensorState(r.URL.Query().Get("state"))
+
+	sensors, err := api.tenantSensors(userId)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	sensorIds := make([]uuid.UUID, 0)
+	for _, sensor := range sensors {
+		sensorIds = append(sensorIds, sensor.Id)
+	}
+	statuses, err := api.sensorStatusRegistry.GetStatuses(sensorIds)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	statusOfSensor := make(map[uuid.UUID]*storage.SensorStatus)
+	for _, status := range statuses {
+		statusOfSensor[status.SensorId] = status
+	}
+
+	now := time.Now()
+	result := make([]interface{}, 0)
+	for _, sensor := range sensors {
+		res := newSensorResponse(sensor, statusOfSensor[sensor.Id], now)
+		if len(state) == 0 || res.Status.State == state {
+			result = append(result, api.sensorResponseView(res, userId))
+		}
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(result)
+}
+
+//tenantSensors returns the sensors of the user and of all organizations the user is a member of
+func (api *weatherRestApi) tenantSensors(userId string) ([]*storage.WeatherSensor, error) {
+	sensors, err := api.sensorRegistry.GetSensorsOfUser(userId)
+	if err != nil {
+		return nil, err
+	}
+
+	organizations, err := api.organizationRegistry.GetOrganizationsOfUser(userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, organization := range organizations {
+		organizationSensors, err := api.sensorRegistry.GetSensorsOfOrganization(organization.Id)
+		if err != nil {
+			return nil, err
+		}
+		sensors = append(sensors, organizationSensors...)
+	}
+	return sensors, nil
+}
@@ -0,0 +1,98 @@
+package api
+
+import (
+	"encoding/json"
+	"net/http"
+	"weather-data/storage"
+
+	"github.com/gorilla/mux"
+)
+
+func (api *weatherRestApi) getValueTypesHandler(w http.ResponseWriter, r *http.Request) {
+	valueTypes, err := api.valueTypeCatalog.GetValueTypes()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(valueTypes)
+}
+
+func (api *weatherRestApi) getValueTypeHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := storage.SensorValueType(vars["name"])
+
+	valueType, err := api.valueTypeCatalog.GetValueType(name)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(valueType)
+}
+
+func (api *weatherRestApi) addValueTypeHandler(w http.ResponseWriter, r *http.Request) {
+	valueType := new(storage.ValueTypeDefinition)
+
+	err := json.NewDecoder(r.Body).Decode(valueType)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	valueType, err = api.valueTypeCatalog.AddValueType(valueType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(valueType)
+}
+
+func (api *weatherRestApi) updateValueTypeHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := storage.SensorValueType(vars["name"])
+
+	valueType, err := api.valueTypeCatalog.GetValueType(name)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	err = json.NewDecoder(r.Body).Decode(valueType)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	valueType.Name = name
+
+	err = api.valueTypeCatalog.UpdateValueType(valueType)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(valueType)
+}
+
+func (api *weatherRestApi) deleteValueTypeHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	name := storage.SensorValueType(vars["name"])
+
+	err := api.valueTypeCatalog.DeleteValueType(name)
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
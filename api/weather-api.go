@@ -2,11 +2,15 @@ package api
 
 import (
 	"weather-data/weathersource"
+
+	"github.com/google/uuid"
 )
 
 //WeatherAPI is the common interface for different apis
 type WeatherAPI interface {
 	Start() error
 	Close()
+	CanReadSensor(sensorId uuid.UUID, userId string) bool
+	OnAlertRuleDeleted(callback AlertRuleDeletedFunc)
 	weathersource.WeatherSource
 }
@@ -0,0 +1,198 @@
+package api
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//auditReason is the optional key of the reason of a change, in the query of a deletion and the body of a correction
+const auditReason = "reason"
+
+//deleteWeatherDataHandler deletes the weather data of the sensor from start up to end, both are required
+func (api *weatherRestApi) deleteWeatherDataHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	start, err := time.Parse(time.RFC3339, r.URL.Query().Get("start"))
+	if err != nil {
+		http.Error(w, "invalid start", http.StatusBadRequest)
+		return
+	}
+	end, err := time.Parse(time.RFC3339, r.URL.Query().Get("end"))
+	if err != nil || !start.Before(end) {
+		http.Error(w, "invalid end", http.StatusBadRequest)
+		return
+	}
+
+	scope, err := api.tenantScope(r)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if err := scope.weatherStorage.Delete(sensor.Id, start, end); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api.refreshRollups(sensor, start, end)
+
+	entry := storage.NewAuditEntry(sensor.Id, r.Header.Get(userIdHeader), storage.DataDeleted, start, end, r.URL.Query().Get(auditReason))
+	api.audit(w, entry)
+}
+
+//correctWeatherDataHandler corrects the stored datapoint with the timeStamp of the body, like the body of a new datapoint
+//values set to null are removed from the datapoint, materialized derived values are computed again
+//the correction is audited before the datapoint is changed, so no change is applied without its audit entry
+func (api *weatherRestApi) correctWeatherDataHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.manageableSensor(w, r)
+	if !ok {
+		return
+	}
+
+	var data = make(map[string]interface{})
+	if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	reason, _ := data[auditReason].(string)
+	delete(data, auditReason)
+	var removed []storage.SensorValueType
+	for key, value := range data {
+		if value == nil && key != storage.SensorId && key != storage.TimeStamp {
+			removed = append(removed, storage.SensorValueType(key))
+		}
+	}
+
+	data[storage.SensorId] = sensor.Id
+	correction, err := storage.FromMap(data)
+	if err != nil || correction.TimeStamp.IsZero() {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+	if len(correction.Values) == 0 && len(removed) == 0 {
+		http.Error(w, "no values to correct", http.StatusBadRequest)
+		return
+	}
+
+	valueTypes, err := api.valueTypeCatalog.GetValueTypes()
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if err = storage.ValidateWeatherData(correction, valueTypes); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	scope, err := api.tenantScope(r)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	query := storage.NewWeatherQuery()
+	query.Start = correction.TimeStamp
+	query.End = correction.TimeStamp.Add(time.Nanosecond)
+	query.SensorIds = []uuid.UUID{sensor.Id}
+	stored, err := scope.weatherStorage.GetData(query)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+	if len(stored) == 0 {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	previous := stored[0]
+
+	if config.MaterializeDerivedValues {
+		removed = storage.CorrectDerivedValues(previous, correction, removed, sensor)
+	}
+
+	entry := storage.NewAuditEntry(sensor.Id, r.Header.Get(userIdHeader), storage.DataCorrected, correction.TimeStamp, correction.TimeStamp, reason)
+	entry.Previous = previous.Values
+	entry.Values = make(map[storage.SensorValueType]float64)
+	for valueType, value := range previous.Values {
+		entry.Values[valueType] = value
+	}
+	for valueType, value := range correction.Values {
+		entry.Values[valueType] = value
+	}
+	for _, valueType := range removed {
+		delete(entry.Values, valueType)
+	}
+	if entry, err = api.auditRegistry.AddEntry(entry); err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	_, err = scope.weatherStorage.Correct(correction, removed)
+	if errors.Is(err, storage.ErrNoDataPoint) {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	api.refreshRollups(sensor, correction.TimeStamp, correction.TimeStamp.Add(time.Nanosecond))
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entry)
+}
+
+//getWeatherDataAuditHandler lists the changes to the stored weather data of the sensor, latest first
+func (api *weatherRestApi) getWeatherDataAuditHandler(w http.ResponseWriter, r *http.Request) {
+	sensor, ok := api.ownedSensor(w, r)
+	if !ok {
+		return
+	}
+
+	entries, err := api.auditRegistry.GetEntriesOfSensor(sensor.Id)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entries)
+}
+
+//audit records the change and writes the entry, the change is already applied when the entry can not be saved
+func (api *weatherRestApi) audit(w http.ResponseWriter, entry *storage.AuditEntry) {
+	entry, err := api.auditRegistry.AddEntry(entry)
+	if err != nil {
+		http.Error(w, "change applied, but not audited", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(entry)
+}
+
+//refreshRollups aggregates the changed range again, so the hourly and daily aggregates match the datapoints
+func (api *weatherRestApi) refreshRollups(sensor *storage.WeatherSensor, start time.Time, end time.Time) {
+	if !config.RetentionConfiguration.Enabled {
+		return
+	}
+	retentionStorage, ok := api.weaterStorage.(storage.RetentionStorage)
+	if !ok {
+		return
+	}
+
+	policy := sensor.RetentionPolicy(storage.DefaultRetentionPolicy(config.RetentionConfiguration))
+	if err := storage.RefreshRollups(retentionStorage, start, end, time.Now(), policy); err != nil {
+		log.Print(err)
+	}
+}
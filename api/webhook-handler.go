@@ -0,0 +1,205 @@
+package api
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+)
+
+//defaultDeliveryLimit is the number of deliveries returned without a limit parameter
+var defaultDeliveryLimit int64 = 50
+
+//getWebhooksHandler lists the subscriptions of the user, secrets are only returned on creation
+func (api *weatherRestApi) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := api.webhookRegistry.GetSubscriptionsOfUser(r.Header.Get(userIdHeader))
+	if err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		subscription.Secret = ""
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(subscriptions)
+}
+
+//addWebhookHandler creates a subscription, a secret is generated if none is given
+func (api *weatherRestApi) addWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	subscription := new(storage.WebhookSubscription)
+	err := json.NewDecoder(r.Body).Decode(subscription)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	subscription.UserId = userId
+	if len(subscription.Secret) == 0 {
+		if subscription.Secret, err = generateSecret(); err != nil {
+			http.Error(w, "", http.StatusInternalServerError)
+			return
+		}
+	}
+
+	if err = api.validateWebhookSubscription(subscription, userId); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	subscription, err = api.webhookRegistry.AddSubscription(subscription)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+func (api *weatherRestApi) getWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := api.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	subscription.Secret = ""
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+//updateWebhookHandler changes the subscription, the secret is kept if none is given
+func (api *weatherRestApi) updateWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	userId := r.Header.Get(userIdHeader)
+
+	subscription, ok := api.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+	subscriptionId, secret := subscription.Id, subscription.Secret
+	subscription.Secret = ""
+
+	err := json.NewDecoder(r.Body).Decode(subscription)
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	subscription.Id = subscriptionId
+	subscription.UserId = userId
+	if len(subscription.Secret) == 0 {
+		subscription.Secret = secret
+	}
+
+	if err = api.validateWebhookSubscription(subscription, userId); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	if err = api.webhookRegistry.UpdateSubscription(subscription); err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return
+	}
+
+	subscription.Secret = ""
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(subscription)
+}
+
+func (api *weatherRestApi) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := api.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	if err := api.webhookRegistry.DeleteSubscription(subscription.Id); err != nil {
+		http.Error(w, "", http.StatusNotFound)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+//getWebhookDeliveriesHandler lists the latest deliveries of the subscription, the number is given by the limit parameter
+func (api *weatherRestApi) getWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	subscription, ok := api.ownWebhookSubscription(w, r)
+	if !ok {
+		return
+	}
+
+	limit := defaultDeliveryLimit
+	if value := r.URL.Query().Get("limit"); len(value) != 0 {
+		var err error
+		if limit, err = strconv.ParseInt(value, 10, 64); err != nil || limit <= 0 {
+			http.Error(w, "invalid limit", http.StatusBadRequest)
+			return
+		}
+	}
+
+	deliveries, err := api.webhookRegistry.GetDeliveries(subscription.Id, limit)
+	if err != nil {
+		http.Error(w, "", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Add("content-type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(deliveries)
+}
+
+//ownWebhookSubscription resolves the subscription of the {id} route variable, subscriptions of other users are reported as not found
+func (api *weatherRestApi) ownWebhookSubscription(w http.ResponseWriter, r *http.Request) (*storage.WebhookSubscription, bool) {
+	subscriptionId, err := uuid.Parse(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "", http.StatusBadRequest)
+		return nil, false
+	}
+
+	subscription, err := api.webhookRegistry.GetSubscription(subscriptionId)
+	if err != nil || subscription.UserId != r.Header.Get(userIdHeader) {
+		http.Error(w, "", http.StatusNotFound)
+		return nil, false
+	}
+	return subscription, true
+}
+
+//validateWebhookSubscription checks the subscription, the user has to be allowed to read all its sensors
+func (api *weatherRestApi) validateWebhookSubscription(subscription *storage.WebhookSubscription, userId string) error {
+	if err := subscription.Validate(); err != nil {
+		return err
+	}
+	for _, sensorId := range subscription.SensorIds {
+		if !api.CanReadSensor(sensorId, userId) {
+			return fmt.Errorf("sensor %v does not exist", sensorId)
+		}
+	}
+	for _, valueType := range subscription.ValueTypes {
+		if _, err := api.valueTypeCatalog.GetValueType(valueType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func generateSecret() (string, error) {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(secret), nil
+}
@@ -3,15 +3,27 @@ package config
 import (
 	"os"
 	"strconv"
+	"strings"
 	"time"
 )
 
 type MongoConfig struct {
-	Host       string
-	Database   string
-	Username   string
-	Password   string
-	Collection string
+	Host                      string
+	Database                  string
+	Username                  string
+	Password                  string
+	Collection                string
+	ValueTypeCollection       string
+	ValueTypeSeedCollection   string
+	StationCollection         string
+	OrganizationCollection    string
+	SensorStatusCollection    string
+	AlertRuleCollection       string
+	AlertCollection           string
+	WebhookCollection         string
+	WebhookDeliveryCollection string
+	AuditCollection           string
+	DeletedSensorCollection   string
 }
 
 type InfluxConfig struct {
@@ -30,6 +42,66 @@ type MqttConfig struct {
 	AllowAnonymousAuthentication bool
 }
 
+//MqttPublishConfig configures the republishing of processed weather data to the mqtt-broker
+type MqttPublishConfig struct {
+	Enabled         bool
+	Topic           string //{sensorId} is replaced by the id of the sensor
+	Retain          bool
+	Discovery       bool
+	DiscoveryPrefix string
+}
+
+//Rtl433Config configures the source reading the json events of rtl_433
+type Rtl433Config struct {
+	Input      string //stdin, file, udp or mqtt, empty disables the source
+	File       string
+	UdpAddress string
+	MqttTopic  string
+}
+
+//LineProtocolConfig configures the ingest of the influxdb line protocol
+type LineProtocolConfig struct {
+	SensorTag    string
+	UdpAddress   string //empty disables the udp socket
+	UdpPrecision string
+}
+
+//CoapConfig configures the coap server, an empty address disables the plain udp or the dtls endpoint
+type CoapConfig struct {
+	Address     string
+	DtlsAddress string
+}
+
+//LorawanConfig configures the uplink webhooks of LoRaWAN network servers
+type LorawanConfig struct {
+	PayloadDecoder string //decoder of payloads without decoded fields: cayennelpp or none
+}
+
+//RetentionConfig configures the aggregation and expiry of stored weather data, days of 0 keep a resolution forever
+type RetentionConfig struct {
+	Enabled       bool
+	Interval      time.Duration
+	DataPointDays int
+	HourlyDays    int
+	DailyDays     int
+}
+
+//DeletionConfig configures the soft deletion of sensors and what happens to their weather data once they are purged
+type DeletionConfig struct {
+	Policy        string        //keep, archive or purge, used if a deletion does not name a policy
+	RestoreWindow time.Duration //deleted sensors can be restored within the window, 0 purges them immediately
+	ArchiveDir    string
+	PurgeInterval time.Duration
+}
+
+type NotificationConfig struct {
+	Retries              int
+	RetryDelay           time.Duration
+	Timeout              time.Duration
+	QueueSize            int
+	AllowPrivateNetworks bool //webhooks may reach loopback, link-local and private addresses
+}
+
 type RestConfig struct {
 	AccessControlAllowOriginHeader string
 	Insecure                       bool
@@ -37,14 +109,27 @@ type RestConfig struct {
 	JwtTokenValidationUrl          string
 	UseJwtTokenValidationSecret    bool
 	JwtTokenValidationSecret       string
+	AdminRole                      string
+	MaxBodySize                    int64 //bytes of a request body read at once, e.g. of lorawan uplinks or line protocol batches
 }
 
 var MongoConfiguration = MongoConfig{
-	Host:       getEnv("MONGO_HOST", "localhost:27017"),
-	Database:   getEnv("MONGO_DB", "weathersensors"),
-	Username:   getEnv("MONGO_USER", "admin"),
-	Password:   getEnv("MONGO_PASSWORD", "admin"),
-	Collection: getEnv("MONGO_COLLECTION", "sensors"),
+	Host:                      getEnv("MONGO_HOST", "localhost:27017"),
+	Database:                  getEnv("MONGO_DB", "weathersensors"),
+	Username:                  getEnv("MONGO_USER", "admin"),
+	Password:                  getEnv("MONGO_PASSWORD", "admin"),
+	Collection:                getEnv("MONGO_COLLECTION", "sensors"),
+	ValueTypeCollection:       getEnv("MONGO_VALUE_TYPE_COLLECTION", "valuetypes"),
+	ValueTypeSeedCollection:   getEnv("MONGO_VALUE_TYPE_SEED_COLLECTION", "valuetypeseeds"),
+	StationCollection:         getEnv("MONGO_STATION_COLLECTION", "stations"),
+	OrganizationCollection:    getEnv("MONGO_ORGANIZATION_COLLECTION", "organizations"),
+	SensorStatusCollection:    getEnv("MONGO_SENSOR_STATUS_COLLECTION", "sensorstatus"),
+	AlertRuleCollection:       getEnv("MONGO_ALERT_RULE_COLLECTION", "alertrules"),
+	AlertCollection:           getEnv("MONGO_ALERT_COLLECTION", "alerts"),
+	WebhookCollection:         getEnv("MONGO_WEBHOOK_COLLECTION", "webhooks"),
+	WebhookDeliveryCollection: getEnv("MONGO_WEBHOOK_DELIVERY_COLLECTION", "webhookdeliveries"),
+	AuditCollection:           getEnv("MONGO_AUDIT_COLLECTION", "audit"),
+	DeletedSensorCollection:   getEnv("MONGO_DELETED_SENSOR_COLLECTION", "deletedsensors"),
 }
 
 var InfluxConfiguration = InfluxConfig{
@@ -63,16 +148,85 @@ var MqttConfiguration = MqttConfig{
 	AllowAnonymousAuthentication: getEnvBool("MQTT_ANONYMOUS", false),
 }
 
+var MqttPublishConfiguration = MqttPublishConfig{
+	Enabled:         getEnvBool("MQTT_REPUBLISH", false),
+	Topic:           getEnv("MQTT_REPUBLISH_TOPIC", "weather/{sensorId}/state"),
+	Retain:          getEnvBool("MQTT_REPUBLISH_RETAIN", false),
+	Discovery:       getEnvBool("MQTT_DISCOVERY", false),
+	DiscoveryPrefix: getEnv("MQTT_DISCOVERY_PREFIX", "homeassistant"),
+}
+
+var Rtl433Configuration = Rtl433Config{
+	Input:      getEnv("RTL433_INPUT", ""),
+	File:       getEnv("RTL433_FILE", "rtl_433.json"),
+	UdpAddress: getEnv("RTL433_UDP_ADDRESS", ":1433"),
+	MqttTopic:  getEnv("RTL433_MQTT_TOPIC", "rtl_433/+/events"),
+}
+
+var LineProtocolConfiguration = LineProtocolConfig{
+	SensorTag:    getEnv("LINE_PROTOCOL_SENSOR_TAG", "sensor"),
+	UdpAddress:   getEnv("LINE_PROTOCOL_UDP_ADDRESS", ""),
+	UdpPrecision: getEnv("LINE_PROTOCOL_UDP_PRECISION", "ns"),
+}
+
+var CoapConfiguration = CoapConfig{
+	Address:     getEnv("COAP_ADDRESS", ""),
+	DtlsAddress: getEnv("COAP_DTLS_ADDRESS", ""),
+}
+
+var LorawanConfiguration = LorawanConfig{
+	PayloadDecoder: getEnv("LORAWAN_PAYLOAD_DECODER", "cayennelpp"),
+}
+
+var RetentionConfiguration = RetentionConfig{
+	Enabled:       getEnvBool("RETENTION_ENABLED", false),
+	Interval:      getEnvDuration("RETENTION_INTERVAL", time.Hour),
+	DataPointDays: getEnvInt("RETENTION_DATAPOINT_DAYS", 30),
+	HourlyDays:    getEnvInt("RETENTION_HOURLY_DAYS", 730),
+	DailyDays:     getEnvInt("RETENTION_DAILY_DAYS", 0),
+}
+
+var DeletionConfiguration = DeletionConfig{
+	Policy:        getEnv("SENSOR_DELETION_POLICY", "keep"),
+	RestoreWindow: getEnvDuration("SENSOR_RESTORE_WINDOW", 30*24*time.Hour),
+	ArchiveDir:    getEnv("SENSOR_ARCHIVE_DIR", "archive"),
+	PurgeInterval: getEnvDuration("SENSOR_PURGE_INTERVAL", time.Hour),
+}
+
+var NotificationConfiguration = NotificationConfig{
+	Retries:              getEnvInt("NOTIFICATION_RETRIES", 3),
+	RetryDelay:           getEnvDuration("NOTIFICATION_RETRY_DELAY", 5*time.Second),
+	Timeout:              getEnvDuration("NOTIFICATION_TIMEOUT", 10*time.Second),
+	QueueSize:            getEnvInt("NOTIFICATION_QUEUE_SIZE", 100),
+	AllowPrivateNetworks: getEnvBool("WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+}
+
 var RestConfiguration = RestConfig{
 	AccessControlAllowOriginHeader: getEnv("ACCESS_CONTROL_ALLOW_ORIGIN_HEADER", "*"),
 	UseJwtTokenValidationUrl:       getEnvBool("USE_JWT_TOKEN_VALIDATION_URL", false),
 	JwtTokenValidationUrl:          getEnv("JWT_TOKEN_VALIDATION_URL", "localhost:5000"),
 	UseJwtTokenValidationSecret:    getEnvBool("USE_JWT_TOKEN_VALIDATION_SECRET", true),
 	JwtTokenValidationSecret:       getEnv("JWT_TOKEN_VALIDATION_SECRET", "my_token_string"),
+	AdminRole:                      getEnv("ADMIN_ROLE", "admins"),
+	MaxBodySize:                    int64(getEnvInt("REST_MAX_BODY_SIZE", 10<<20)),
 }
 
 var AllowUnregisteredSensors = getEnvBool("ALLOW_UNREGISTERED_SENSORS", false)
 
+var MaterializeDerivedValues = getEnvBool("MATERIALIZE_DERIVED_VALUES", false)
+
+//WebhookDeliveryRetention is the time the delivery log of webhook subscriptions is kept
+var WebhookDeliveryRetention = getEnvDuration("WEBHOOK_DELIVERY_RETENTION", 7*24*time.Hour)
+
+//NoDataCheckInterval is the interval in which registered sensors are checked for missing data
+var NoDataCheckInterval = getEnvDuration("NO_DATA_CHECK_INTERVAL", time.Minute)
+
+//SensorReportingInterval is the expected interval between two datapoints of sensors without an own setting
+var SensorReportingInterval = getEnvDuration("SENSOR_REPORTING_INTERVAL", time.Minute)
+
+//SensorStatusFlushInterval is the interval in which changed sensor statuses are written to mongodb
+var SensorStatusFlushInterval = getEnvDuration("SENSOR_STATUS_FLUSH_INTERVAL", 5*time.Second)
+
 //helper
 func getEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
@@ -93,6 +247,37 @@ func getEnvBool(key string, fallback bool) bool {
 	return fallback
 }
 
+func getEnvInt(key string, fallback int) int {
+	if value, ok := os.LookupEnv(key); ok {
+		if iValue, err := strconv.Atoi(value); err == nil {
+			return iValue
+		}
+	}
+
+	return fallback
+}
+
+//getEnvList splits a comma separated value, empty entries are skipped
+func getEnvList(key string) []string {
+	var list []string
+	for _, value := range strings.Split(getEnv(key, ""), ",") {
+		if value = strings.TrimSpace(value); len(value) != 0 {
+			list = append(list, value)
+		}
+	}
+	return list
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	if value, ok := os.LookupEnv(key); ok {
+		if fValue, err := strconv.ParseFloat(value, 64); err == nil {
+			return fValue
+		}
+	}
+
+	return fallback
+}
+
 func getEnvDuration(key string, fallback time.Duration) time.Duration {
 	if value, ok := os.LookupEnv(key); ok {
 		if iValue, err := strconv.ParseInt(value, 10, 64); err == nil {
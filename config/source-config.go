@@ -0,0 +1,191 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"time"
+)
+
+//source types of SourceConfig
+const (
+	MqttSource         = "mqtt"
+	LineProtocolSource = "lineprotocol"
+	CoapSource         = "coap"
+	Rtl433Source       = "rtl433"
+	HttpSource         = "http"
+	ReplaySource       = "replay"
+	SyntheticSource    = "synthetic"
+)
+
+//SourceConfig declares one weather source instance, only the settings of its type are used
+//settings missing in the sources file are taken from the environment variables
+type SourceConfig struct {
+	Name         string
+	Type         string
+	Mqtt         MqttConfig
+	LineProtocol LineProtocolConfig
+	Coap         CoapConfig
+	Rtl433       Rtl433Config
+	Http         HttpSourceConfig
+	Replay       ReplayConfig
+	Synthetic    SyntheticConfig
+	RecordFile   string //records the traffic of the source in the format of the replay source, mqtt sources record the messages
+}
+
+//HttpSourceConfig configures a source polling a json document with the values of one sensor
+type HttpSourceConfig struct {
+	Url      string
+	SensorId string
+	Interval int //polling interval in seconds
+	Headers  map[string]string
+}
+
+//ReplayConfig configures a source replaying a recording of mqtt messages or weather data
+type ReplayConfig struct {
+	File           string
+	Speed          float64 //1 replays in real time, 10 ten times as fast, 0 as fast as possible
+	Loop           bool
+	KeepTimeStamps bool //otherwise the timestamps are moved to the time of the replay
+}
+
+//SyntheticConfig configures a source generating synthetic weather data of virtual sensors
+type SyntheticConfig struct {
+	SensorIds []string
+	Interval  int //interval in seconds
+}
+
+//SourcesFile is a json file with a list of SourceConfig, if empty the sources are configured by the environment variables
+var SourcesFile = getEnv("SOURCES_FILE", "")
+
+//RecordFile records the traffic of the sources configured by the environment variables
+var RecordFile = getEnv("RECORD_FILE", "")
+
+var SyntheticConfiguration = SyntheticConfig{
+	SensorIds: getEnvList("SYNTHETIC_SENSORS"),
+	Interval:  int(getEnvDuration("SYNTHETIC_INTERVAL", time.Minute) / time.Second),
+}
+
+var ReplayConfiguration = ReplayConfig{
+	File:           getEnv("REPLAY_FILE", ""),
+	Speed:          getEnvFloat("REPLAY_SPEED", 1),
+	Loop:           getEnvBool("REPLAY_LOOP", false),
+	KeepTimeStamps: getEnvBool("REPLAY_KEEP_TIMESTAMPS", false),
+}
+
+//LoadSourceConfigs returns the sources of the SourcesFile or the sources configured by the environment variables
+func LoadSourceConfigs() ([]SourceConfig, error) {
+	if len(SourcesFile) == 0 {
+		return environmentSourceConfigs(), nil
+	}
+
+	content, err := ioutil.ReadFile(SourcesFile)
+	if err != nil {
+		return nil, err
+	}
+
+	var entries []json.RawMessage
+	if err := json.Unmarshal(content, &entries); err != nil {
+		return nil, err
+	}
+
+	sources := make([]SourceConfig, 0, len(entries))
+	names := make(map[string]bool)
+	for i, entry := range entries {
+		source := newSourceConfig("", "")
+		if err := json.Unmarshal(entry, &source); err != nil {
+			return nil, fmt.Errorf("source %v: %v", i, err)
+		}
+		if len(source.Name) == 0 {
+			source.Name = fmt.Sprintf("%v-%v", source.Type, i)
+		}
+		if err := source.validate(); err != nil {
+			return nil, err
+		}
+		if names[source.Name] {
+			return nil, fmt.Errorf("duplicate source name %v", source.Name)
+		}
+		names[source.Name] = true
+		sources = append(sources, source)
+	}
+	return sources, nil
+}
+
+//environmentSourceConfigs returns the sources enabled by the environment variables, an empty MQTT_HOST disables the mqtt source
+func environmentSourceConfigs() []SourceConfig {
+	var sources []SourceConfig
+	if len(MqttConfiguration.Host) != 0 {
+		sources = append(sources, newSourceConfig(MqttSource, MqttSource))
+	}
+	if len(LineProtocolConfiguration.UdpAddress) != 0 {
+		sources = append(sources, newSourceConfig(LineProtocolSource, LineProtocolSource))
+	}
+	if len(CoapConfiguration.Address) != 0 || len(CoapConfiguration.DtlsAddress) != 0 {
+		sources = append(sources, newSourceConfig(CoapSource, CoapSource))
+	}
+	if len(Rtl433Configuration.Input) != 0 {
+		sources = append(sources, newSourceConfig(Rtl433Source, Rtl433Source))
+	}
+	for i := range sources {
+		sources[i].RecordFile = RecordFile
+	}
+	//generated and replayed data is not recorded, a replay would record its own recording
+	if len(SyntheticConfiguration.SensorIds) != 0 {
+		sources = append(sources, newSourceConfig(SyntheticSource, SyntheticSource))
+	}
+	if len(ReplayConfiguration.File) != 0 {
+		sources = append(sources, newSourceConfig(ReplaySource, ReplaySource))
+	}
+	return sources
+}
+
+//newSourceConfig returns a source with the settings of the environment variables
+func newSourceConfig(name, sourceType string) SourceConfig {
+	return SourceConfig{
+		Name:         name,
+		Type:         sourceType,
+		Mqtt:         MqttConfiguration,
+		LineProtocol: LineProtocolConfiguration,
+		Coap:         CoapConfiguration,
+		Rtl433:       Rtl433Configuration,
+		Http:         HttpSourceConfig{Interval: 60},
+		Replay:       ReplayConfiguration,
+		Synthetic:    SyntheticConfiguration,
+	}
+}
+
+func (source *SourceConfig) validate() error {
+	switch source.Type {
+	case MqttSource:
+		if len(source.Mqtt.Host) == 0 {
+			return fmt.Errorf("source %v: missing mqtt host", source.Name)
+		}
+	case LineProtocolSource:
+		if len(source.LineProtocol.UdpAddress) == 0 {
+			return fmt.Errorf("source %v: missing udp address", source.Name)
+		}
+	case CoapSource:
+		if len(source.Coap.Address) == 0 && len(source.Coap.DtlsAddress) == 0 {
+			return fmt.Errorf("source %v: missing coap address", source.Name)
+		}
+	case Rtl433Source:
+		if len(source.Rtl433.Input) == 0 {
+			return fmt.Errorf("source %v: missing rtl_433 input", source.Name)
+		}
+	case HttpSource:
+		if len(source.Http.Url) == 0 || len(source.Http.SensorId) == 0 || source.Http.Interval <= 0 {
+			return fmt.Errorf("source %v: url, sensor id and interval are required", source.Name)
+		}
+	case ReplaySource:
+		if len(source.Replay.File) == 0 || source.Replay.Speed < 0 {
+			return fmt.Errorf("source %v: missing replay file or negative speed", source.Name)
+		}
+	case SyntheticSource:
+		if len(source.Synthetic.SensorIds) == 0 || source.Synthetic.Interval <= 0 {
+			return fmt.Errorf("source %v: sensor ids and interval are required", source.Name)
+		}
+	default:
+		return fmt.Errorf("source %v: unknown type %v", source.Name, source.Type)
+	}
+	return nil
+}
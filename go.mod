@@ -4,10 +4,14 @@ go 1.16
 
 require (
 	github.com/eclipse/paho.mqtt.golang v1.3.5
+	github.com/fxamacker/cbor/v2 v2.4.0
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/google/uuid v1.3.0
 	github.com/gorilla/handlers v1.5.1
 	github.com/gorilla/mux v1.8.0
 	github.com/influxdata/influxdb-client-go/v2 v2.5.0
+	github.com/pion/dtls/v2 v2.1.5
+	github.com/plgd-dev/go-coap/v2 v2.6.0
 	go.mongodb.org/mongo-driver v1.7.1
+	golang.org/x/net v0.7.0 // indirect
 )
@@ -3,17 +3,30 @@ package main
 import (
 	"log"
 	"os"
+	"weather-data/alerting"
 	"weather-data/api"
 	"weather-data/config"
 	"weather-data/storage"
 	"weather-data/weathersource"
+	"weather-data/webhook"
 )
 
 var sensorRegistry storage.SensorRegistry
+var valueTypeCatalog storage.ValueTypeCatalog
+var stationRegistry storage.StationRegistry
+var organizationRegistry storage.OrganizationRegistry
+var sensorStatusRegistry storage.SensorStatusRegistry
+var alertRegistry storage.AlertRegistry
+var alertEvaluator *alerting.Evaluator
+var webhookRegistry storage.WebhookRegistry
+var auditRegistry storage.AuditRegistry
 var weatherStorage storage.WeatherStorage
-var weatherSource weathersource.WeatherSource
+var sourceManager *weathersource.SourceManager
 var weatherAPI api.WeatherAPI
 
+//processedWeatherData publishes weather data after it has been stored
+var processedWeatherData weathersource.WeatherSourceBase
+
 func main() {
 	log.SetOutput(os.Stdout)
 
@@ -24,23 +37,110 @@ func main() {
 	}
 	defer sensorRegistry.Close()
 
+	//setup new valueTypeCatalog -> MongodbValueTypeCatalog
+	if valueTypeCatalog, err = storage.NewMongodbValueTypeCatalog(config.MongoConfiguration); err != nil {
+		log.Fatal(err)
+	}
+	defer valueTypeCatalog.Close()
+
+	//setup new stationRegistry -> MongodbStationRegistry
+	if stationRegistry, err = storage.NewMongodbStationRegistry(config.MongoConfiguration); err != nil {
+		log.Fatal(err)
+	}
+	defer stationRegistry.Close()
+
+	//setup new organizationRegistry -> MongodbOrganizationRegistry
+	if organizationRegistry, err = storage.NewMongodbOrganizationRegistry(config.MongoConfiguration); err != nil {
+		log.Fatal(err)
+	}
+	defer organizationRegistry.Close()
+
+	//setup new sensorStatusRegistry -> MongodbSensorStatusRegistry
+	if sensorStatusRegistry, err = storage.NewMongodbSensorStatusRegistry(config.MongoConfiguration); err != nil {
+		log.Fatal(err)
+	}
+	defer sensorStatusRegistry.Close()
+
+	//setup alerting -> MongodbAlertRegistry, notifications via webhooks
+	if alertRegistry, err = storage.NewMongodbAlertRegistry(config.MongoConfiguration); err != nil {
+		log.Fatal(err)
+	}
+	defer alertRegistry.Close()
+
+	notifier := alerting.NewWebhookNotifier(config.NotificationConfiguration)
+	defer notifier.Close()
+	alertEvaluator = alerting.NewEvaluator(alertRegistry, notifier)
+
+	noDataChecker := alerting.NewNoDataChecker(sensorRegistry, sensorStatusRegistry, alertRegistry, notifier, config.NoDataCheckInterval)
+	noDataChecker.Start()
+	defer noDataChecker.Close()
+
 	//setup a new weatherstorage -> InfluxDB
 	if weatherStorage, err = storage.NewInfluxStorage(config.InfluxConfiguration); err != nil {
 		log.Fatal(err)
 	}
 	defer weatherStorage.Close()
 
-	//setup new weatherData source -> mqtt
-	if weatherSource, err = weathersource.NewMqttSource(config.MqttConfiguration); err != nil {
+	//aggregate and expire the stored weather data -> opt-in, as it deletes data
+	if config.RetentionConfiguration.Enabled {
+		retentionStorage, ok := weatherStorage.(storage.RetentionStorage)
+		if !ok {
+			log.Fatal("the weather storage does not support retention")
+		}
+		retentionJob := storage.NewRetentionJob(retentionStorage, sensorRegistry, config.RetentionConfiguration)
+		retentionJob.Start()
+		defer retentionJob.Close()
+	}
+
+	//setup new webhookRegistry -> MongodbWebhookRegistry
+	if webhookRegistry, err = storage.NewMongodbWebhookRegistry(config.MongoConfiguration, int32(config.WebhookDeliveryRetention.Seconds())); err != nil {
+		log.Fatal(err)
+	}
+	defer webhookRegistry.Close()
+
+	//setup new auditRegistry -> MongodbAuditRegistry
+	if auditRegistry, err = storage.NewMongodbAuditRegistry(config.MongoConfiguration); err != nil {
 		log.Fatal(err)
 	}
-	defer weatherSource.Close()
-	weatherSource.OnNewWeatherData(handleNewWeatherData)
+	defer auditRegistry.Close()
+
+	//purge deleted sensors after their restore window -> keep, archive or purge their weather data
+	if _, err := storage.ParseDeletionPolicy(config.DeletionConfiguration.Policy, storage.KeepData); err != nil {
+		log.Fatal(err)
+	}
+	sensorPurger := storage.NewSensorPurger(sensorRegistry, weatherStorage, auditRegistry, alertRegistry, webhookRegistry, stationRegistry, config.DeletionConfiguration)
+	sensorPurger.Start()
+	defer sensorPurger.Close()
+
+	//setup the weatherData sources -> SOURCES_FILE or environment variables, each source is supervised independently
+	sourceConfigs, err := config.LoadSourceConfigs()
+	if err != nil {
+		log.Fatal(err)
+	}
+	sourceManager = weathersource.NewSourceManager(sourceConfigs, sensorRegistry, valueTypeCatalog)
 
 	//setup a API -> REST
-	weatherAPI = api.NewRestAPI(":10000", weatherStorage, sensorRegistry, config.RestConfiguration)
+	weatherAPI = api.NewRestAPI(":10000", weatherStorage, sensorRegistry, valueTypeCatalog, stationRegistry, organizationRegistry, sensorStatusRegistry, alertRegistry, webhookRegistry, auditRegistry, sensorPurger, sourceManager, config.RestConfiguration)
 	defer weatherAPI.Close()
 	weatherAPI.OnNewWeatherData(handleNewWeatherData)
+	weatherAPI.OnAlertRuleDeleted(alertEvaluator.RemoveRule)
+
+	//setup outbound webhooks -> deliveries of the stored weather data
+	dispatcher := webhook.NewDispatcher(webhookRegistry, weatherAPI.CanReadSensor, config.NotificationConfiguration)
+	defer dispatcher.Close()
+	processedWeatherData.OnNewWeatherData(dispatcher.HandleWeatherData)
+
+	//setup republishing -> mqtt, optionally with home assistant discovery
+	if config.MqttPublishConfiguration.Enabled {
+		publisher := weathersource.NewMqttPublisher(config.MqttConfiguration, config.MqttPublishConfiguration, sensorRegistry, valueTypeCatalog)
+		defer publisher.Close()
+		processedWeatherData.OnNewWeatherData(publisher.HandleWeatherData)
+	}
+
+	//start the weatherData sources -> mqtt, line protocol, coap, rtl_433, http
+	sourceManager.OnNewWeatherData(handleNewWeatherData)
+	sourceManager.Start()
+	defer sourceManager.Close()
 
 	log.Print("Application is running")
 	err = weatherAPI.Start()
@@ -50,9 +150,42 @@ func main() {
 }
 
 func handleNewWeatherData(wd *storage.WeatherData) {
-	if config.AllowUnregisteredSensors {
-		weatherStorage.Save(wd)
-	} else if exist, err := sensorRegistry.ExistSensor(wd.SensorId); err == nil && exist {
-		weatherStorage.Save(wd)
+	sensor, err := sensorRegistry.GetSensor(wd.SensorId)
+	if err != nil && !config.AllowUnregisteredSensors {
+		return
+	}
+	//deleted sensors can be restored, their weather data is not accepted as data of an unregistered sensor
+	if err != nil {
+		if _, err := sensorRegistry.GetDeletedSensor(wd.SensorId); err == nil {
+			log.Printf("dropped weather data of deleted sensor %v", wd.SensorId)
+			return
+		}
 	}
+
+	if sensor != nil {
+		sensor.Calibrate(wd)
+	}
+
+	if valueTypes, err := valueTypeCatalog.GetValueTypes(); err == nil {
+		for _, err := range storage.RemoveInvalidValues(wd, valueTypes) {
+			log.Printf("dropped value of sensor %v: %v", wd.SensorId, err)
+		}
+	}
+
+	if len(wd.Values) == 0 {
+		return
+	}
+
+	if config.MaterializeDerivedValues {
+		storage.MaterializeDerivedValues(wd, sensor)
+	}
+
+	if err := weatherStorage.Save(wd); err != nil {
+		log.Print(err)
+		return
+	}
+
+	sensorStatusRegistry.RecordData(wd)
+	alertEvaluator.Evaluate(wd, sensor)
+	processedWeatherData.NewWeatherData(wd)
 }
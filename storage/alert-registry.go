@@ -0,0 +1,160 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+)
+
+//AlertRegistry is the interface for different implementations of the storage of alert rules and alerts
+type AlertRegistry interface {
+	AddRule(rule *AlertRule) (*AlertRule, error)
+	GetRule(uuid.UUID) (*AlertRule, error)
+	GetRulesOfSensor(sensorId uuid.UUID) ([]*AlertRule, error)
+	UpdateRule(*AlertRule) error
+	DeleteRule(uuid.UUID) error
+	SaveAlert(alert *Alert) error
+	GetOpenAlerts(sensorIds []uuid.UUID) ([]*Alert, error)
+	GetOpenAlertsOfKind(kind AlertKind) ([]*Alert, error)
+	GetAlertsOfSensor(sensorId uuid.UUID) ([]*Alert, error)
+	Close() error
+}
+
+//Comparator compares a value with the threshold of an alert rule
+type Comparator string
+
+const (
+	Below        Comparator = "<"
+	BelowOrEqual Comparator = "<="
+	Above        Comparator = ">"
+	AboveOrEqual Comparator = ">="
+)
+
+//AlertRule fires an alert when a value of the sensor breaches the threshold for at least the duration
+type AlertRule struct {
+	Id         uuid.UUID
+	SensorId   uuid.UUID
+	Name       string
+	ValueType  SensorValueType
+	Comparator Comparator
+	Threshold  float64
+	Duration   int      //seconds the threshold has to be breached before the alert fires
+	Hysteresis float64  //distance from the threshold the value has to return by to resolve the alert
+	Webhooks   []string //urls notified when the alert fires or resolves
+	Disabled   bool
+}
+
+//Validate checks the settings of the rule
+func (rule *AlertRule) Validate() error {
+	if len(rule.ValueType) == 0 {
+		return errors.New("value type is missing")
+	}
+	switch rule.Comparator {
+	case Below, BelowOrEqual, Above, AboveOrEqual:
+	default:
+		return fmt.Errorf("unknown comparator %v", rule.Comparator)
+	}
+	if rule.Duration < 0 {
+		return fmt.Errorf("duration %v is negative", rule.Duration)
+	}
+	if rule.Hysteresis < 0 {
+		return fmt.Errorf("hysteresis %v is negative", rule.Hysteresis)
+	}
+	return ValidateWebhooks(rule.Webhooks)
+}
+
+//ValidateWebhooks checks that all webhooks are absolute http or https urls
+//loopback, link-local and private hosts are rejected unless private networks are allowed for notifications
+func ValidateWebhooks(webhooks []string) error {
+	for _, webhook := range webhooks {
+		u, err := url.Parse(webhook)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || len(u.Host) == 0 {
+			return fmt.Errorf("invalid webhook url %v", webhook)
+		}
+		if !config.NotificationConfiguration.AllowPrivateNetworks && isPrivateHost(u.Hostname()) {
+			return fmt.Errorf("webhook url %v points to a private network", webhook)
+		}
+	}
+	return nil
+}
+
+//IsBreached checks if the value breaches the threshold
+func (rule *AlertRule) IsBreached(value float64) bool {
+	switch rule.Comparator {
+	case Below:
+		return value < rule.Threshold
+	case BelowOrEqual:
+		return value <= rule.Threshold
+	case Above:
+		return value > rule.Threshold
+	case AboveOrEqual:
+		return value >= rule.Threshold
+	}
+	return false
+}
+
+//IsRecovered checks if the value has returned beyond the threshold by the hysteresis
+func (rule *AlertRule) IsRecovered(value float64) bool {
+	switch rule.Comparator {
+	case Below, BelowOrEqual:
+		return value >= rule.Threshold+rule.Hysteresis && !rule.IsBreached(value)
+	case Above, AboveOrEqual:
+		return value <= rule.Threshold-rule.Hysteresis && !rule.IsBreached(value)
+	}
+	return false
+}
+
+//AlertKind distinguishes alerts of threshold rules from alerts of silent sensors
+type AlertKind string
+
+const (
+	ThresholdAlert AlertKind = "threshold"
+	NoDataAlert    AlertKind = "nodata"
+)
+
+//AlertState is the state of an alert
+type AlertState string
+
+const (
+	Firing   AlertState = "firing"
+	Resolved AlertState = "resolved"
+)
+
+//Alert is raised by a rule and stays firing until it is resolved
+type Alert struct {
+	Id         uuid.UUID
+	Kind       AlertKind
+	RuleId     uuid.UUID //empty for no-data alerts
+	SensorId   uuid.UUID
+	State      AlertState
+	Message    string
+	Value      float64
+	FiredAt    time.Time
+	ResolvedAt *time.Time
+	Webhooks   []string `json:"-"` //urls of no-data alerts notified when the alert resolves, kept if the settings of the sensor are removed
+}
+
+//NewAlert creates a firing alert of the rule
+func NewAlert(rule *AlertRule, value float64, firedAt time.Time) *Alert {
+	alert := new(Alert)
+	alert.Id = uuid.New()
+	alert.Kind = ThresholdAlert
+	alert.RuleId = rule.Id
+	alert.SensorId = rule.SensorId
+	alert.State = Firing
+	alert.Value = value
+	alert.FiredAt = firedAt
+	alert.Message = fmt.Sprintf("%v %v %v %v", rule.Name, rule.ValueType, rule.Comparator, rule.Threshold)
+	return alert
+}
+
+//Resolve marks the alert as resolved
+func (alert *Alert) Resolve(value float64, resolvedAt time.Time) {
+	alert.State = Resolved
+	alert.Value = value
+	alert.ResolvedAt = &resolvedAt
+}
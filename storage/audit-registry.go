@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//AuditRegistry is the interface for different implementations of the audit log of changes to stored weather data
+type AuditRegistry interface {
+	AddEntry(entry *AuditEntry) (*AuditEntry, error)
+	GetEntriesOfSensor(sensorId uuid.UUID) ([]*AuditEntry, error)
+	Close() error
+}
+
+//AuditAction is the kind of change to stored weather data
+type AuditAction string
+
+const (
+	DataDeleted   AuditAction = "delete"
+	DataCorrected AuditAction = "correct"
+	DataArchived  AuditAction = "archive" //the weather data of a deleted sensor was archived and deleted
+	DataPurged    AuditAction = "purge"   //the weather data of a deleted sensor was deleted
+)
+
+//AuditEntry records who changed the stored weather data of a sensor, when and why
+type AuditEntry struct {
+	Id        uuid.UUID
+	SensorId  uuid.UUID
+	UserId    string
+	Action    AuditAction
+	Start     time.Time                   //start of the deleted range or the timestamp of the corrected datapoint
+	End       time.Time                   //end of the deleted range or the timestamp of the corrected datapoint
+	Previous  map[SensorValueType]float64 `json:",omitempty"` //values of the corrected datapoint before the correction
+	Values    map[SensorValueType]float64 `json:",omitempty"` //values of the corrected datapoint after the correction
+	Reason    string                      `json:",omitempty"`
+	ChangedAt time.Time
+}
+
+//NewAuditEntry creates the entry of a change by the user now
+func NewAuditEntry(sensorId uuid.UUID, userId string, action AuditAction, start time.Time, end time.Time, reason string) *AuditEntry {
+	entry := new(AuditEntry)
+	entry.SensorId = sensorId
+	entry.UserId = userId
+	entry.Action = action
+	entry.Start = start
+	entry.End = end
+	entry.Reason = reason
+	entry.ChangedAt = time.Now()
+	return entry
+}
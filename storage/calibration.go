@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"errors"
+	"time"
+)
+
+//Calibration is a linear correction of a SensorValueType: corrected = raw * Gain + Offset
+//a Gain of 0 is treated as 1, so a calibration may consist of an Offset only
+type Calibration struct {
+	ValueType  SensorValueType
+	Offset     float64
+	Gain       float64
+	ValidFrom  *time.Time
+	ValidUntil *time.Time
+}
+
+//Validate checks the calibration settings
+func (calibration *Calibration) Validate() error {
+	if len(calibration.ValueType) == 0 {
+		return errors.New("calibration value type is missing")
+	}
+	if calibration.ValidFrom != nil && calibration.ValidUntil != nil && calibration.ValidUntil.Before(*calibration.ValidFrom) {
+		return errors.New("calibration validity ends before it starts")
+	}
+	return nil
+}
+
+//IsValidAt checks if the calibration is valid at the given time
+func (calibration *Calibration) IsValidAt(timestamp time.Time) bool {
+	if calibration.ValidFrom != nil && timestamp.Before(*calibration.ValidFrom) {
+		return false
+	}
+	if calibration.ValidUntil != nil && !timestamp.Before(*calibration.ValidUntil) {
+		return false
+	}
+	return true
+}
+
+//Apply the calibration to a raw value
+func (calibration *Calibration) Apply(raw float64) float64 {
+	gain := calibration.Gain
+	if gain == 0 {
+		gain = 1
+	}
+	return raw*gain + calibration.Offset
+}
+
+//GetCalibration returns the calibration of the value type valid at the given time
+//if several calibrations are valid, the one with the latest ValidFrom is used
+func (sensor *WeatherSensor) GetCalibration(valueType SensorValueType, timestamp time.Time) *Calibration {
+	var result *Calibration
+	for i := range sensor.Calibrations {
+		calibration := &sensor.Calibrations[i]
+		if calibration.ValueType != valueType || !calibration.IsValidAt(timestamp) {
+			continue
+		}
+		if result == nil || (calibration.ValidFrom != nil && (result.ValidFrom == nil || calibration.ValidFrom.After(*result.ValidFrom))) {
+			result = calibration
+		}
+	}
+	return result
+}
+
+//Calibrate applies the calibrations of the sensor to the WeatherData, the uncorrected values are kept as RawValues
+func (sensor *WeatherSensor) Calibrate(data *WeatherData) *WeatherData {
+	if data.RawValues == nil {
+		data.RawValues = make(map[SensorValueType]float64)
+	}
+	for valueType, value := range data.Values {
+		if calibration := sensor.GetCalibration(valueType, data.TimeStamp); calibration != nil {
+			data.RawValues[valueType] = value
+			data.Values[valueType] = calibration.Apply(value)
+		}
+	}
+	return data
+}
+
+//RecalculateCalibration re-applies the current calibrations of the sensor to its stored weather data between start and end
+//values without stored raw value are treated as uncorrected. returns the number of updated datapoints
+func RecalculateCalibration(weatherStorage WeatherStorage, sensor *WeatherSensor, start time.Time, end time.Time) (int, error) {
+	query := NewWeatherQuery()
+	query.Start = start
+	query.End = end
+	query.SensorIds = append(query.SensorIds, sensor.Id)
+
+	dataPoints, err := weatherStorage.GetData(query)
+	if err != nil {
+		return 0, err
+	}
+
+	rawDataPoints, err := weatherStorage.GetRawData(query)
+	if err != nil {
+		return 0, err
+	}
+
+	rawValues := make(map[time.Time]map[SensorValueType]float64)
+	for _, raw := range rawDataPoints {
+		rawValues[raw.TimeStamp] = raw.Values
+	}
+
+	updated := 0
+	for _, data := range dataPoints {
+		recalculated := NewWeatherData()
+		recalculated.SensorId = data.SensorId
+		recalculated.TimeStamp = data.TimeStamp
+
+		changed := false
+		for valueType, value := range data.Values {
+			raw, hasRaw := rawValues[data.TimeStamp][valueType]
+			calibration := sensor.GetCalibration(valueType, data.TimeStamp)
+			if !hasRaw && calibration == nil {
+				recalculated.Values[valueType] = value
+				continue
+			}
+			if !hasRaw {
+				raw = value
+			}
+
+			corrected := raw
+			if calibration != nil {
+				corrected = calibration.Apply(raw)
+			}
+
+			recalculated.RawValues[valueType] = raw
+			recalculated.Values[valueType] = corrected
+			changed = changed || corrected != value
+		}
+
+		if !changed {
+			continue
+		}
+
+		//stored derived values depend on the corrected values
+		for _, derived := range derivedValues {
+			if _, exists := recalculated.Values[derived.Name]; exists {
+				if value, ok := derived.Compute(recalculated.Values, sensor); ok {
+					recalculated.Values[derived.Name] = value
+				}
+			}
+		}
+
+		if err = weatherStorage.Save(recalculated); err != nil {
+			return updated, err
+		}
+		updated++
+	}
+
+	return updated, nil
+}
@@ -0,0 +1,232 @@
+package storage
+
+import (
+	"math"
+
+	"github.com/google/uuid"
+)
+
+const (
+	DewPoint         SensorValueType = "dewpoint"
+	HeatIndex        SensorValueType = "heatindex"
+	WindChill        SensorValueType = "windchill"
+	AbsoluteHumidity SensorValueType = "absolutehumidity"
+	Humidex          SensorValueType = "humidex"
+	SeaLevelPressure SensorValueType = "sealevelpressure"
+)
+
+//derivedValueFunc computes a derived value, returns false if the value can not be computed
+type derivedValueFunc func(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool)
+
+//DerivedValue is a SensorValueType computed from other values of the same WeatherData
+type DerivedValue struct {
+	Name     SensorValueType
+	Requires []SensorValueType
+	compute  derivedValueFunc
+}
+
+var derivedValues = []*DerivedValue{
+	{DewPoint, []SensorValueType{Temperature, Humidity}, computeDewPoint},
+	{HeatIndex, []SensorValueType{Temperature, Humidity}, computeHeatIndex},
+	{WindChill, []SensorValueType{Temperature, WindSpeed}, computeWindChill},
+	{AbsoluteHumidity, []SensorValueType{Temperature, Humidity}, computeAbsoluteHumidity},
+	{Humidex, []SensorValueType{Temperature, Humidity}, computeHumidex},
+	{SeaLevelPressure, []SensorValueType{Pressure, Temperature}, computeSeaLevelPressure},
+}
+
+//GetDerivedValues returns all known DerivedValues
+func GetDerivedValues() []*DerivedValue {
+	return derivedValues
+}
+
+//Compute the derived value for the given values, returns false if not all required values exist
+func (derived *DerivedValue) Compute(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool) {
+	for _, required := range derived.Requires {
+		if _, exists := values[required]; !exists {
+			return 0, false
+		}
+	}
+	return derived.compute(values, sensor)
+}
+
+//WithDerivedValueDependencies returns a copy of the query additionally querying the values needed by requested derived values
+func (query *WeatherQuery) WithDerivedValueDependencies() *WeatherQuery {
+	result := *query
+	result.Values = make(map[SensorValueType]bool)
+	for k, v := range query.Values {
+		result.Values[k] = v
+	}
+
+	for _, derived := range derivedValues {
+		if query.Values[derived.Name] {
+			for _, required := range derived.Requires {
+				result.Values[required] = true
+			}
+		}
+	}
+	return &result
+}
+
+//AddDerivedValues computes all derived values requested by the query which are not stored already
+//values only queried as dependency of derived values are removed afterwards
+func AddDerivedValues(dataPoints []*WeatherData, query *WeatherQuery, sensors []*WeatherSensor) []*WeatherData {
+	dependencies := make(map[SensorValueType]bool)
+
+	for _, data := range dataPoints {
+		sensor := findSensor(sensors, data.SensorId)
+		for _, derived := range derivedValues {
+			if !query.Values[derived.Name] {
+				continue
+			}
+			for _, required := range derived.Requires {
+				if !query.Values[required] {
+					dependencies[required] = true
+				}
+			}
+			if _, exists := data.Values[derived.Name]; exists {
+				continue
+			}
+			if value, ok := derived.Compute(data.Values, sensor); ok {
+				data.Values[derived.Name] = value
+			}
+		}
+	}
+
+	for _, data := range dataPoints {
+		for dependency := range dependencies {
+			delete(data.Values, dependency)
+		}
+	}
+
+	return dataPoints
+}
+
+//MaterializeDerivedValues adds all computable derived values to the WeatherData, sensor may be nil
+func MaterializeDerivedValues(data *WeatherData, sensor *WeatherSensor) *WeatherData {
+	for _, derived := range derivedValues {
+		if _, exists := data.Values[derived.Name]; exists {
+			continue
+		}
+		if value, ok := derived.Compute(data.Values, sensor); ok {
+			data.Values[derived.Name] = value
+		}
+	}
+	return data
+}
+
+//CorrectDerivedValues adds the materialized derived values of a corrected datapoint to the correction
+//returns the removed value types, extended by the derived values which can no longer be computed
+//stored derived values are only recomputed if they were materialized from the previous values, measured and corrected values are kept
+func CorrectDerivedValues(previous *WeatherData, correction *WeatherData, removed []SensorValueType, sensor *WeatherSensor) []SensorValueType {
+	values := make(map[SensorValueType]float64)
+	for valueType, value := range previous.Values {
+		values[valueType] = value
+	}
+	for valueType, value := range correction.Values {
+		values[valueType] = value
+	}
+	isRemoved := make(map[SensorValueType]bool)
+	for _, valueType := range removed {
+		delete(values, valueType)
+		isRemoved[valueType] = true
+	}
+
+	for _, derived := range derivedValues {
+		if _, corrected := correction.Values[derived.Name]; corrected || isRemoved[derived.Name] {
+			continue
+		}
+		previousValue, stored := previous.Values[derived.Name]
+		if stored {
+			if materialized, ok := derived.Compute(previous.Values, sensor); !ok || materialized != previousValue {
+				continue
+			}
+		}
+
+		delete(values, derived.Name)
+		if value, ok := derived.Compute(values, sensor); ok {
+			values[derived.Name] = value
+			correction.Values[derived.Name] = value
+		} else if stored {
+			removed = append(removed, derived.Name)
+		}
+	}
+	return removed
+}
+
+func findSensor(sensors []*WeatherSensor, sensorId uuid.UUID) *WeatherSensor {
+	for _, sensor := range sensors {
+		if sensor != nil && sensor.Id == sensorId {
+			return sensor
+		}
+	}
+	return nil
+}
+
+//computeDewPoint with the magnus formula in °C
+func computeDewPoint(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool) {
+	temperature, humidity := values[Temperature], values[Humidity]
+	if humidity <= 0 {
+		return 0, false
+	}
+	gamma := math.Log(humidity/100) + 17.62*temperature/(243.12+temperature)
+	return 243.12 * gamma / (17.62 - gamma), true
+}
+
+//computeHeatIndex with the NOAA (Rothfusz) regression in °C
+func computeHeatIndex(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool) {
+	t, _ := ConvertUnit(values[Temperature], Celsius, Fahrenheit)
+	rh := values[Humidity]
+
+	heatIndex := 0.5 * (t + 61 + (t-68)*1.2 + rh*0.094)
+	if (heatIndex+t)/2 >= 80 {
+		heatIndex = -42.379 + 2.04901523*t + 10.14333127*rh - 0.22475541*t*rh -
+			0.00683783*t*t - 0.05481717*rh*rh + 0.00122874*t*t*rh +
+			0.00085282*t*rh*rh - 0.00000199*t*t*rh*rh
+		if rh < 13 && t >= 80 && t <= 112 {
+			heatIndex -= (13 - rh) / 4 * math.Sqrt((17-math.Abs(t-95))/17)
+		} else if rh > 85 && t >= 80 && t <= 87 {
+			heatIndex += (rh - 85) / 10 * (87 - t) / 5
+		}
+	}
+
+	result, _ := ConvertUnit(heatIndex, Fahrenheit, Celsius)
+	return result, true
+}
+
+//computeWindChill with the formula of the NWS / Environment Canada in °C
+//outside of the valid range (above 10°C or below 4.8km/h) the temperature is returned
+func computeWindChill(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool) {
+	temperature := values[Temperature]
+	windSpeed, _ := ConvertUnit(values[WindSpeed], MeterPerSecond, KilometerPerHour)
+	if temperature > 10 || windSpeed < 4.8 {
+		return temperature, true
+	}
+	v := math.Pow(windSpeed, 0.16)
+	return 13.12 + 0.6215*temperature - 11.37*v + 0.3965*temperature*v, true
+}
+
+//computeAbsoluteHumidity in g/m³
+func computeAbsoluteHumidity(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool) {
+	temperature, humidity := values[Temperature], values[Humidity]
+	saturationPressure := 6.112 * math.Exp(17.67*temperature/(temperature+243.5))
+	return saturationPressure * humidity * 2.1674 / (273.15 + temperature), true
+}
+
+//computeHumidex with the formula of Environment Canada in °C
+func computeHumidex(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool) {
+	dewPoint, ok := computeDewPoint(values, sensor)
+	if !ok {
+		return 0, false
+	}
+	vaporPressure := 6.11 * math.Exp(5417.7530*(1/273.16-1/(273.15+dewPoint)))
+	return values[Temperature] + 0.5555*(vaporPressure-10), true
+}
+
+//computeSeaLevelPressure with the barometric formula in hPa, needs the elevation of the sensor
+func computeSeaLevelPressure(values map[SensorValueType]float64, sensor *WeatherSensor) (float64, bool) {
+	if sensor == nil || sensor.Elevation == nil {
+		return 0, false
+	}
+	pressure, temperature, elevation := values[Pressure], values[Temperature], *sensor.Elevation
+	return pressure * math.Pow(1-0.0065*elevation/(temperature+0.0065*elevation+273.15), -5.257), true
+}
@@ -0,0 +1,119 @@
+package storage
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+)
+
+//IngestProtocol is a protocol of an ingest source which identifies sensors by its own ids
+type IngestProtocol string
+
+const (
+	WundergroundProtocol IngestProtocol = "wunderground"
+	EcowittProtocol      IngestProtocol = "ecowitt"
+	Rtl433Protocol       IngestProtocol = "rtl433"
+	CoapProtocol         IngestProtocol = "coap"
+	LorawanProtocol      IngestProtocol = "lorawan"
+)
+
+var devEuiRegex = regexp.MustCompile("^[0-9a-f]{16}$")
+
+//ingestProtocols are the known protocols, true if the protocol requires a password besides the id
+var ingestProtocols = map[IngestProtocol]bool{
+	WundergroundProtocol: true,
+	EcowittProtocol:      false,
+	Rtl433Protocol:       false,
+	CoapProtocol:         true,
+	LorawanProtocol:      false,
+}
+
+//secretIdProtocols are the protocols whose id authenticates the uploads, so the id is only written like a password
+var secretIdProtocols = map[IngestProtocol]bool{
+	EcowittProtocol: true,
+}
+
+//ExternalId maps the id of a sensor within an ingest protocol to the registered sensor
+type ExternalId struct {
+	Protocol IngestProtocol
+	Id       string //e.g. station ID of weather underground, PASSKEY of ecowitt, model/id/channel of rtl_433, psk identity of coap, DevEUI of lorawan
+	Password string //only written, never returned by the api, the pre-shared key of coap
+}
+
+//Validate checks the external id
+func (externalId *ExternalId) Validate() error {
+	if _, known := ingestProtocols[externalId.Protocol]; !known {
+		return fmt.Errorf("unknown protocol %v", externalId.Protocol)
+	}
+	if len(externalId.Id) == 0 {
+		return errors.New("external id is missing")
+	}
+	if ingestProtocols[externalId.Protocol] && len(externalId.Password) == 0 {
+		return fmt.Errorf("protocol %v requires a password", externalId.Protocol)
+	}
+	if externalId.Protocol == LorawanProtocol && !devEuiRegex.MatchString(externalId.Id) {
+		return fmt.Errorf("invalid DevEUI %v", externalId.Id)
+	}
+	return nil
+}
+
+//IsSecret checks if the id authenticates the uploads of the protocol
+func (externalId *ExternalId) IsSecret() bool {
+	return secretIdProtocols[externalId.Protocol]
+}
+
+//Normalize converts the id to the format of the protocol, DevEUIs are stored as lower case hex
+func (externalId *ExternalId) Normalize() {
+	if externalId.Protocol == LorawanProtocol {
+		externalId.Id = NormalizeDevEui(externalId.Id)
+	}
+}
+
+//MarshalJSON omits the password and secret ids like the PASSKEY of ecowitt
+func (externalId ExternalId) MarshalJSON() ([]byte, error) {
+	id := externalId.Id
+	if secretIdProtocols[externalId.Protocol] {
+		id = ""
+	}
+	return json.Marshal(struct {
+		Protocol IngestProtocol
+		Id       string `json:",omitempty"`
+	}{externalId.Protocol, id})
+}
+
+//ExternalId returns the external id of the sensor within the protocol, nil if there is none
+func (sensor *WeatherSensor) ExternalId(protocol IngestProtocol) *ExternalId {
+	for i := range sensor.ExternalIds {
+		if sensor.ExternalIds[i].Protocol == protocol {
+			return &sensor.ExternalIds[i]
+		}
+	}
+	return nil
+}
+
+//NormalizeExternalIds converts the external ids to the format of their protocols
+func (sensor *WeatherSensor) NormalizeExternalIds() {
+	for i := range sensor.ExternalIds {
+		sensor.ExternalIds[i].Normalize()
+	}
+}
+
+//KeepExternalIdSecrets takes over the secret ids of external ids which are sent without id
+//and the passwords of unchanged external ids which are sent without password
+func (sensor *WeatherSensor) KeepExternalIdSecrets(previous []ExternalId) {
+	for i := range sensor.ExternalIds {
+		externalId := &sensor.ExternalIds[i]
+		for _, previousId := range previous {
+			if previousId.Protocol != externalId.Protocol {
+				continue
+			}
+			if secretIdProtocols[externalId.Protocol] && len(externalId.Id) == 0 {
+				externalId.Id = previousId.Id
+			}
+			if len(externalId.Password) == 0 && previousId.Id == externalId.Id {
+				externalId.Password = previousId.Password
+			}
+		}
+	}
+}
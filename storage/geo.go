@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"errors"
+	"math"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+//earthRadius in meters
+var earthRadius = 6371008.8
+
+//GeoPoint is a GeoJSON point, used for the geospatial index of the sensor registry
+type GeoPoint struct {
+	Type        string    `bson:"type" json:"type"`
+	Coordinates []float64 `bson:"coordinates" json:"coordinates"`
+}
+
+//NewGeoPoint creates a GeoJSON point, GeoJSON uses longitude before latitude
+func NewGeoPoint(longitude float64, latitude float64) *GeoPoint {
+	return &GeoPoint{Type: "Point", Coordinates: []float64{longitude, latitude}}
+}
+
+//GeoBox is a bounding box given by its south-west and north-east corner
+type GeoBox struct {
+	MinLongitude float64
+	MinLatitude  float64
+	MaxLongitude float64
+	MaxLatitude  float64
+}
+
+//Validate checks the coordinates of the box
+func (box *GeoBox) Validate() error {
+	if box.MinLatitude < -90 || box.MaxLatitude > 90 || box.MinLatitude > box.MaxLatitude {
+		return errors.New("invalid latitude range")
+	}
+	if box.MinLongitude < -180 || box.MaxLongitude > 180 || box.MinLongitude > box.MaxLongitude {
+		return errors.New("invalid longitude range")
+	}
+	return nil
+}
+
+//Contains checks if the point is within the box
+func (box *GeoBox) Contains(latitude float64, longitude float64) bool {
+	return latitude >= box.MinLatitude && latitude <= box.MaxLatitude &&
+		longitude >= box.MinLongitude && longitude <= box.MaxLongitude
+}
+
+//DistanceBetween two coordinates in meters (haversine formula)
+func DistanceBetween(latitude1 float64, longitude1 float64, latitude2 float64, longitude2 float64) float64 {
+	toRad := math.Pi / 180
+	dLat := (latitude2 - latitude1) * toRad
+	dLon := (longitude2 - longitude1) * toRad
+	a := math.Sin(dLat/2)*math.Sin(dLat/2) +
+		math.Cos(latitude1*toRad)*math.Cos(latitude2*toRad)*math.Sin(dLon/2)*math.Sin(dLon/2)
+	return 2 * earthRadius * math.Asin(math.Min(1, math.Sqrt(a)))
+}
+
+//boxAround returns the bounding box of a circle
+func boxAround(latitude float64, longitude float64, radius float64) GeoBox {
+	dLat := radius / earthRadius * 180 / math.Pi
+	box := GeoBox{
+		MinLatitude: math.Max(-90, latitude-dLat),
+		MaxLatitude: math.Min(90, latitude+dLat),
+	}
+
+	cos := math.Cos(math.Max(math.Abs(box.MinLatitude), math.Abs(box.MaxLatitude)) * math.Pi / 180)
+	if cos < 1e-9 || dLat/cos >= 180 {
+		box.MinLongitude, box.MaxLongitude = -180, 180
+		return box
+	}
+	dLon := dLat / cos
+	box.MinLongitude = math.Max(-180, longitude-dLon)
+	box.MaxLongitude = math.Min(180, longitude+dLon)
+	return box
+}
+
+//geoCell is a cell of the geoGridIndex with a size of one degree
+type geoCell struct {
+	latitude  int
+	longitude int
+}
+
+func cellOf(latitude float64, longitude float64) geoCell {
+	return geoCell{int(math.Floor(latitude)), int(math.Floor(longitude))}
+}
+
+//geoGridIndex is a simple spatial index for sensors, sorting them in cells of one degree
+type geoGridIndex struct {
+	cells map[geoCell]map[uuid.UUID]*WeatherSensor
+}
+
+func newGeoGridIndex() *geoGridIndex {
+	index := new(geoGridIndex)
+	index.cells = make(map[geoCell]map[uuid.UUID]*WeatherSensor)
+	return index
+}
+
+func (index *geoGridIndex) add(sensor *WeatherSensor) {
+	cell := cellOf(sensor.Latitude, sensor.Longitude)
+	if _, exists := index.cells[cell]; !exists {
+		index.cells[cell] = make(map[uuid.UUID]*WeatherSensor)
+	}
+	index.cells[cell][sensor.Id] = sensor
+}
+
+func (index *geoGridIndex) remove(sensorId uuid.UUID) {
+	for cell, sensors := range index.cells {
+		if _, exists := sensors[sensorId]; exists {
+			delete(sensors, sensorId)
+			if len(sensors) == 0 {
+				delete(index.cells, cell)
+			}
+			return
+		}
+	}
+}
+
+//within returns all sensors inside the box
+func (index *geoGridIndex) within(box GeoBox) []*WeatherSensor {
+	result := make([]*WeatherSensor, 0)
+	minCell := cellOf(box.MinLatitude, box.MinLongitude)
+	maxCell := cellOf(box.MaxLatitude, box.MaxLongitude)
+
+	//large boxes cover more cells than there are, in that case all cells are checked
+	if (maxCell.latitude-minCell.latitude+1)*(maxCell.longitude-minCell.longitude+1) > len(index.cells) {
+		for _, sensors := range index.cells {
+			result = appendSensorsInBox(result, sensors, box)
+		}
+		return result
+	}
+
+	for latitude := minCell.latitude; latitude <= maxCell.latitude; latitude++ {
+		for longitude := minCell.longitude; longitude <= maxCell.longitude; longitude++ {
+			result = appendSensorsInBox(result, index.cells[geoCell{latitude, longitude}], box)
+		}
+	}
+	return result
+}
+
+//near returns all sensors within the radius (in meters), ordered by distance
+func (index *geoGridIndex) near(latitude float64, longitude float64, radius float64) []*WeatherSensor {
+	result := make([]*WeatherSensor, 0)
+	for _, sensor := range index.within(boxAround(latitude, longitude, radius)) {
+		if DistanceBetween(latitude, longitude, sensor.Latitude, sensor.Longitude) <= radius {
+			result = append(result, sensor)
+		}
+	}
+	sort.Slice(result, func(p, q int) bool {
+		return DistanceBetween(latitude, longitude, result[p].Latitude, result[p].Longitude) <
+			DistanceBetween(latitude, longitude, result[q].Latitude, result[q].Longitude)
+	})
+	return result
+}
+
+func appendSensorsInBox(result []*WeatherSensor, sensors map[uuid.UUID]*WeatherSensor, box GeoBox) []*WeatherSensor {
+	for _, sensor := range sensors {
+		if box.Contains(sensor.Latitude, sensor.Longitude) {
+			result = append(result, sensor)
+		}
+	}
+	return result
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//ArchiveParts are the datapoints, the uncorrected values and the aggregates of each resolution, the measurements removed by Delete
+func (storage *influxStorage) ArchiveParts() []string {
+	parts := []string{""}
+	for _, resolution := range Resolutions {
+		if resolution.Window() != 0 {
+			parts = append(parts, string(resolution))
+		}
+	}
+	return append(parts, rawArchivePart)
+}
+
+//archiveMeasurement returns the measurement of the archive part
+func (storage *influxStorage) archiveMeasurement(part string) (string, error) {
+	if part == rawArchivePart {
+		return storage.rawMeasurement, nil
+	}
+	for _, known := range storage.ArchiveParts() {
+		if known == part {
+			return storage.resolutionMeasurement(Resolution(part)), nil
+		}
+	}
+	return "", fmt.Errorf("unknown archive part %v", part)
+}
+
+//Archive streams the part of the weather data of the sensor ordered by time, one json object with sensorId, timeStamp and the values per line
+func (storage *influxStorage) Archive(sensorId uuid.UUID, part string, w io.Writer) error {
+	measurement, err := storage.archiveMeasurement(part)
+	if err != nil {
+		return err
+	}
+
+	fluxQuery := fmt.Sprintf(`from(bucket:"%v")
+ |> range(start: 0, stop: %v)
+ |> filter(fn: (r) => r["_measurement"] == "%v")
+ |> filter(fn: (r) => r["sensorId"] == "%v")
+ |> pivot(rowKey: ["_time"], columnKey: ["_field"], valueColumn: "_value")
+ |> group()
+ |> sort(columns: ["_time"])`, fluxString(storage.config.Bucket), EndOfTime.Format(time.RFC3339Nano), fluxString(measurement), sensorId)
+
+	result, err := storage.client.QueryAPI(storage.config.Organization).Query(context.Background(), fluxQuery)
+	if err != nil {
+		return err
+	}
+
+	encoder := json.NewEncoder(w)
+	for result.Next() {
+		record := result.Record()
+		line := map[string]interface{}{
+			SensorId:  sensorId.String(),
+			TimeStamp: record.Time().Format(time.RFC3339Nano),
+		}
+		//the pivoted fields are the value types, the other columns start with an underscore or are tags
+		for column, value := range record.Values() {
+			if value, ok := value.(float64); ok && !strings.HasPrefix(column, "_") {
+				line[column] = value
+			}
+		}
+		if err := encoder.Encode(line); err != nil {
+			return err
+		}
+	}
+	return result.Err()
+}
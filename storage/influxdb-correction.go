@@ -0,0 +1,109 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	influxdb2 "github.com/influxdata/influxdb-client-go/v2"
+	"github.com/influxdata/influxdb-client-go/v2/api/write"
+)
+
+//Delete removes the datapoints, uncorrected values and aggregates of the sensor from start up to end
+func (storage *influxStorage) Delete(sensorId uuid.UUID, start time.Time, end time.Time) error {
+	//the stop of an influx delete is inclusive, the end of a query is not
+	return storage.deletePoints(fmt.Sprintf("sensorId=\"%v\"", sensorId), start, end.Add(-time.Nanosecond))
+}
+
+//Correct rewrites the datapoint with the corrected values, the uncorrected values of corrected or removed value types are dropped
+//as a field can not be deleted in InfluxDB, the datapoint is deleted and written again, the original datapoint is restored if that fails
+func (storage *influxStorage) Correct(correction *WeatherData, removed []SensorValueType) (*WeatherData, error) {
+	query := NewWeatherQuery()
+	query.Start = correction.TimeStamp
+	query.End = correction.TimeStamp.Add(time.Nanosecond)
+	query.SensorIds = []uuid.UUID{correction.SensorId}
+
+	previous, err := storage.GetData(query)
+	if err != nil {
+		return nil, err
+	}
+	if len(previous) == 0 {
+		return nil, ErrNoDataPoint
+	}
+	raw, err := storage.GetRawData(query)
+	if err != nil {
+		return nil, err
+	}
+
+	original := NewWeatherData()
+	original.SensorId = correction.SensorId
+	original.TimeStamp = previous[0].TimeStamp
+	original.Values = previous[0].Values
+	if len(raw) != 0 {
+		original.RawValues = raw[0].Values
+	}
+
+	corrected := NewWeatherData()
+	corrected.SensorId = original.SensorId
+	corrected.TimeStamp = original.TimeStamp
+	for valueType, value := range original.Values {
+		corrected.Values[valueType] = value
+	}
+	for valueType, value := range original.RawValues {
+		corrected.RawValues[valueType] = value
+	}
+	for valueType, value := range correction.Values {
+		corrected.Values[valueType] = value
+		delete(corrected.RawValues, valueType)
+	}
+	for _, valueType := range removed {
+		delete(corrected.Values, valueType)
+		delete(corrected.RawValues, valueType)
+	}
+
+	if err := storage.replacePoint(corrected); err != nil {
+		if restoreErr := storage.replacePoint(original); restoreErr != nil {
+			return nil, fmt.Errorf("correction failed: %v, restoring the datapoint failed: %v", err, restoreErr)
+		}
+		return nil, err
+	}
+
+	return previous[0], nil
+}
+
+//replacePoint deletes the datapoint and its uncorrected values at the time of the data and writes the data instead
+func (storage *influxStorage) replacePoint(data *WeatherData) error {
+	for _, measurement := range []string{storage.measurement, storage.rawMeasurement} {
+		predicate := fmt.Sprintf("_measurement=\"%v\" AND sensorId=\"%v\"", fluxString(measurement), data.SensorId)
+		if err := storage.deletePoints(predicate, data.TimeStamp, data.TimeStamp); err != nil {
+			return err
+		}
+	}
+
+	tags := map[string]string{"sensorId": data.SensorId.String()}
+	points := make([]*write.Point, 0)
+	if len(data.Values) != 0 {
+		points = append(points, influxdb2.NewPoint(storage.measurement, tags, fieldsOf(data.Values), data.TimeStamp))
+	}
+	if len(data.RawValues) != 0 {
+		points = append(points, influxdb2.NewPoint(storage.rawMeasurement, tags, fieldsOf(data.RawValues), data.TimeStamp))
+	}
+	if len(points) == 0 {
+		return nil
+	}
+	writeAPI := storage.client.WriteAPIBlocking(storage.config.Organization, storage.config.Bucket)
+	return writeAPI.WritePoint(context.Background(), points...)
+}
+
+func (storage *influxStorage) deletePoints(predicate string, start time.Time, stop time.Time) error {
+	return storage.client.DeleteAPI().DeleteWithName(context.Background(), storage.config.Organization, storage.config.Bucket, start, stop, predicate)
+}
+
+func fieldsOf(values map[SensorValueType]float64) map[string]interface{} {
+	fields := make(map[string]interface{})
+	for k, v := range values {
+		fields[string(k)] = v
+	}
+	return fields
+}
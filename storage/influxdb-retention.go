@@ -0,0 +1,149 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//resolutionMeasurement returns the measurement of the resolution, the aggregates are kept next to the datapoints
+func (storage *influxStorage) resolutionMeasurement(resolution Resolution) string {
+	if window := resolution.Window(); window != 0 {
+		return fmt.Sprintf("%v-%v", storage.measurement, resolution)
+	}
+	return storage.measurement
+}
+
+//vectorMean averages directions in degrees by their unit vectors, so 350° and 10° average to 0° instead of 180°
+var vectorMean = `(column, tables=<-) => tables
+  |> reduce(identity: {sin: 0.0, cos: 0.0}, fn: (r, accumulator) => ({
+      sin: accumulator.sin + math.sin(x: r._value * math.pi / 180.0),
+      cos: accumulator.cos + math.cos(x: r._value * math.pi / 180.0)}))
+  |> map(fn: (r) => ({r with _value: math.mod(x: math.atan2(y: r.sin, x: r.cos) * 180.0 / math.pi + 360.0, y: 360.0)}))
+  |> drop(columns: ["sin", "cos"])`
+
+//fieldFilter returns a flux predicate matching the fields of the value types
+func fieldFilter(valueTypes []SensorValueType) string {
+	fields := make([]string, 0)
+	for _, valueType := range valueTypes {
+		fields = append(fields, fmt.Sprintf("r[\"_field\"] == \"%v\"", fluxString(valueType)))
+	}
+	return strings.Join(fields, " or ")
+}
+
+//Rollup aggregates the next finer resolution into the resolution, cumulative value types by their maximum, directions by their vector mean and all others by their mean
+//the aggregates are written with the start of their window, so repeating a rollup overwrites the same points
+func (storage *influxStorage) Rollup(resolution Resolution, start time.Time, end time.Time) error {
+	if resolution.Window() == 0 {
+		return fmt.Errorf("resolution %v is not aggregated", resolution)
+	}
+	var source Resolution
+	for i, known := range Resolutions {
+		if known == resolution {
+			source = Resolutions[i-1]
+		}
+	}
+
+	cumulativeFilter := fieldFilter(cumulativeValueTypes)
+	directionFilter := fieldFilter(directionValueTypes)
+
+	aggregates := []struct {
+		function string
+		filter   string
+	}{
+		{"mean", fmt.Sprintf("not (%v or %v)", cumulativeFilter, directionFilter)},
+		{"max", cumulativeFilter},
+		{vectorMean, directionFilter},
+	}
+
+	for _, aggregate := range aggregates {
+		fluxQuery := fmt.Sprintf(`import "math"
+
+from(bucket:"%v")
+ |> range(start: %v, stop: %v)
+ |> filter(fn: (r) => r["_measurement"] == "%v")
+ |> filter(fn: (r) => %v)
+ |> aggregateWindow(every: %v, fn: %v, createEmpty: false, timeSrc: "_start")
+ |> set(key: "_measurement", value: "%v")
+ |> to(bucket: "%v", org: "%v")`,
+			fluxString(storage.config.Bucket), start.Format(time.RFC3339), end.Format(time.RFC3339), fluxString(storage.resolutionMeasurement(source)),
+			aggregate.filter, resolution, aggregate.function, fluxString(storage.resolutionMeasurement(resolution)),
+			fluxString(storage.config.Bucket), fluxString(storage.config.Organization))
+
+		result, err := storage.client.QueryAPI(storage.config.Organization).Query(context.Background(), fluxQuery)
+		if err != nil {
+			return err
+		}
+		for result.Next() {
+		}
+		if result.Err() != nil {
+			return result.Err()
+		}
+	}
+	return nil
+}
+
+//LatestRollup returns the time of the latest aggregate of the resolution, zero if there is none
+func (storage *influxStorage) LatestRollup(resolution Resolution) (time.Time, error) {
+	fluxQuery := fmt.Sprintf(`from(bucket:"%v")
+ |> range(start: 0)
+ |> filter(fn: (r) => r["_measurement"] == "%v")
+ |> group()
+ |> max(column: "_time")`, fluxString(storage.config.Bucket), fluxString(storage.resolutionMeasurement(resolution)))
+
+	result, err := storage.client.QueryAPI(storage.config.Organization).Query(context.Background(), fluxQuery)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	var latest time.Time
+	for result.Next() {
+		if result.Record().Time().After(latest) {
+			latest = result.Record().Time()
+		}
+	}
+	return latest, result.Err()
+}
+
+//GetStoredSensorIds returns the values of the sensorId tag
+func (storage *influxStorage) GetStoredSensorIds() ([]uuid.UUID, error) {
+	fluxQuery := fmt.Sprintf(`import "influxdata/influxdb/schema"
+schema.tagValues(bucket: "%v", tag: "sensorId", start: 0)`, fluxString(storage.config.Bucket))
+
+	result, err := storage.client.QueryAPI(storage.config.Organization).Query(context.Background(), fluxQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	sensorIds := make([]uuid.UUID, 0)
+	for result.Next() {
+		value, ok := result.Record().Value().(string)
+		if !ok {
+			return nil, errors.New("unexpected sensorId tag value")
+		}
+		if sensorId, err := uuid.Parse(value); err == nil {
+			sensorIds = append(sensorIds, sensorId)
+		}
+	}
+	return sensorIds, result.Err()
+}
+
+//Expire deletes the weather data of the sensor in the resolution before the time, uncorrected values expire with the datapoints
+func (storage *influxStorage) Expire(sensorId uuid.UUID, resolution Resolution, before time.Time) error {
+	measurements := []string{storage.resolutionMeasurement(resolution)}
+	if resolution == NoAggregation {
+		measurements = append(measurements, storage.rawMeasurement)
+	}
+
+	for _, measurement := range measurements {
+		predicate := fmt.Sprintf("_measurement=\"%v\" AND sensorId=\"%v\"", fluxString(measurement), sensorId)
+		if err := storage.deletePoints(predicate, time.Unix(0, 0), before); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -15,9 +15,10 @@ import (
 
 //influxStorage is the Storage implementation for InfluxDB
 type influxStorage struct {
-	config      config.InfluxConfig
-	measurement string
-	client      influxdb2.Client
+	config         config.InfluxConfig
+	measurement    string
+	rawMeasurement string
+	client         influxdb2.Client
 }
 
 //NewInfluxStorage Factory
@@ -26,6 +27,7 @@ func NewInfluxStorage(cfg config.InfluxConfig) (*influxStorage, error) {
 	influx.config = cfg
 	influx.client = influxdb2.NewClient(cfg.Host, cfg.Token)
 	influx.measurement = "weather-data"
+	influx.rawMeasurement = "weather-data-raw"
 	log.Print("Successfully created influx-client")
 	return influx, nil
 }
@@ -48,36 +50,91 @@ func (storage *influxStorage) Save(data *WeatherData) error {
 
 	writeAPI := storage.client.WriteAPI(storage.config.Organization, storage.config.Bucket)
 	writeAPI.WritePoint(datapoint)
+
+	//uncorrected values of calibrated sensors are kept in a separate measurement
+	if len(data.RawValues) > 0 {
+		rawFields := make(map[string]interface{})
+		for k, v := range data.RawValues {
+			rawFields[string(k)] = v
+		}
+		writeAPI.WritePoint(influxdb2.NewPoint(storage.rawMeasurement, tags, rawFields, data.TimeStamp))
+	}
 	return nil
 }
 
-//GetData datapoints from InfluxDB
+//GetData datapoints or aggregates of the resolution of the query from InfluxDB
 func (storage *influxStorage) GetData(query *WeatherQuery) ([]*WeatherData, error) {
-	fluxQuery := storage.createFluxQuery(query)
+	fluxQuery := storage.createFluxQuery(query, storage.resolutionMeasurement(query.Resolution))
+	res, err := storage.executeFluxQuery(fluxQuery)
+	return res, err
+}
+
+//GetRawData uncorrected values of calibrated datapoints from InfluxDB
+func (storage *influxStorage) GetRawData(query *WeatherQuery) ([]*WeatherData, error) {
+	fluxQuery := storage.createFluxQuery(query, storage.rawMeasurement)
 	res, err := storage.executeFluxQuery(fluxQuery)
 	return res, err
 }
 
-func (storage *influxStorage) createFluxQuery(query *WeatherQuery) string {
+//GetLatestData the latest values of each sensor from InfluxDB, merged into one datapoint per sensor
+func (storage *influxStorage) GetLatestData(sensorIds []uuid.UUID) ([]*WeatherData, error) {
+	if len(sensorIds) == 0 {
+		return make([]*WeatherData, 0), nil
+	}
+
+	query := NewWeatherQuery()
+	query.Start = time.Unix(0, 0)
+	query.End = time.Now()
+	query.SensorIds = sensorIds
+
+	fluxQuery := fmt.Sprintf("%v \n |> last()", storage.createFluxQuery(query, storage.measurement))
+	res, err := storage.executeFluxQuery(fluxQuery)
+	if err != nil {
+		return nil, err
+	}
+
+	//the last values of the fields of a sensor can have different timestamps
+	latest := make(map[uuid.UUID]*WeatherData)
+	var result []*WeatherData
+	for _, data := range res {
+		merged, exists := latest[data.SensorId]
+		if !exists {
+			latest[data.SensorId] = data
+			result = append(result, data)
+			continue
+		}
+		for k, v := range data.Values {
+			merged.Values[k] = v
+		}
+		if data.TimeStamp.After(merged.TimeStamp) {
+			merged.TimeStamp = data.TimeStamp
+		}
+	}
+
+	return result, nil
+}
+
+func (storage *influxStorage) createFluxQuery(query *WeatherQuery, measurement string) string {
 	fields := ""
 	concat := ""
 	sensorIds := ""
 
 	for sensorValueType, value := range query.Values {
 		if value {
-			fields = fmt.Sprintf("%v %v r[\"_field\"] == \"%v\"", fields, concat, string(sensorValueType))
+			fields = fmt.Sprintf("%v %v r[\"_field\"] == \"%v\"", fields, concat, fluxString(sensorValueType))
 			concat = "or"
 		}
 	}
 	concat = ""
 	for _, id := range query.SensorIds {
-		sensorIds = fmt.Sprintf("%v %v r[\"sensorId\"] == \"%v\"", sensorIds, concat, id)
+		sensorIds = fmt.Sprintf("%v %v r[\"sensorId\"] == \"%v\"", sensorIds, concat, fluxString(id))
 		concat = "or"
 	}
 
-	fromTemplate := fmt.Sprintf("from(bucket:\"%v\")", storage.config.Bucket)
-	rangeTemplate := fmt.Sprintf("|> range(start: %v, stop: %v)", query.Start.Format(time.RFC3339), query.End.Format(time.RFC3339))
-	measurementTemplate := fmt.Sprintf("|> filter(fn: (r) => r[\"_measurement\"] == \"%v\")", storage.measurement)
+	fromTemplate := fmt.Sprintf("from(bucket:\"%v\")", fluxString(storage.config.Bucket))
+	//nanoseconds are kept, so the range of a single datapoint is not empty
+	rangeTemplate := fmt.Sprintf("|> range(start: %v, stop: %v)", query.Start.Format(time.RFC3339Nano), query.End.Format(time.RFC3339Nano))
+	measurementTemplate := fmt.Sprintf("|> filter(fn: (r) => r[\"_measurement\"] == \"%v\")", fluxString(measurement))
 	sensorIdsTemplate := ""
 	if len(sensorIds) > 0 {
 		sensorIdsTemplate = fmt.Sprintf("|> filter(fn: (r) => %v )", strings.Trim(sensorIds, " "))
@@ -135,6 +192,11 @@ func (storage *influxStorage) executeFluxQuery(query string) ([]*WeatherData, er
 	return queryResults, nil
 }
 
+//fluxString escapes a value interpolated into a string literal of a flux query
+func fluxString(value interface{}) string {
+	return strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(fmt.Sprint(value))
+}
+
 func containsWeatherData(weatherData []*WeatherData, sensorId uuid.UUID, timestamp time.Time) (*WeatherData, bool) {
 	for _, val := range weatherData {
 		if val.SensorId == sensorId && val.TimeStamp == timestamp {
@@ -2,19 +2,36 @@ package storage
 
 import (
 	"errors"
+	"sync"
+	"time"
 
 	"github.com/google/uuid"
 )
 
 type inmemorySensorRegistry struct {
 	weatherSensors []*WeatherSensor
+	deletedSensors []*WeatherSensor
+	geoIndex       *geoGridIndex
+	mutex          sync.RWMutex
 }
 
 func NewInmemorySensorRegistry() *inmemorySensorRegistry {
 	sensorRegistry := new(inmemorySensorRegistry)
+	sensorRegistry.geoIndex = newGeoGridIndex()
 	return sensorRegistry
 }
 
+func (registry *inmemorySensorRegistry) RegisterSensor(sensor *WeatherSensor) (*WeatherSensor, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	sensor.Id = uuid.New()
+	sensor.CreatedAt = time.Now().UTC()
+	registry.weatherSensors = append(registry.weatherSensors, sensor)
+	registry.geoIndex.add(sensor)
+	return sensor, nil
+}
+
 func (registry *inmemorySensorRegistry) RegisterSensorByName(name string) (*WeatherSensor, error) {
 	exist, err := registry.ExistSensorName(name)
 	if err != nil {
@@ -25,12 +42,13 @@ func (registry *inmemorySensorRegistry) RegisterSensorByName(name string) (*Weat
 	}
 	sensor := new(WeatherSensor)
 	sensor.Name = name
-	sensor.Id = uuid.New()
-	registry.weatherSensors = append(registry.weatherSensors, sensor)
-	return sensor, nil
+	return registry.RegisterSensor(sensor)
 }
 
 func (registry *inmemorySensorRegistry) ExistSensorName(name string) (bool, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
 	for _, s := range registry.weatherSensors {
 		if s.Name == name {
 			return true, nil
@@ -40,6 +58,13 @@ func (registry *inmemorySensorRegistry) ExistSensorName(name string) (bool, erro
 }
 
 func (registry *inmemorySensorRegistry) ResolveSensorById(sensorId uuid.UUID) (*WeatherSensor, error) {
+	return registry.GetSensor(sensorId)
+}
+
+func (registry *inmemorySensorRegistry) GetSensor(sensorId uuid.UUID) (*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
 	for _, s := range registry.weatherSensors {
 		if s.Id == sensorId {
 			return s, nil
@@ -49,6 +74,9 @@ func (registry *inmemorySensorRegistry) ResolveSensorById(sensorId uuid.UUID) (*
 }
 
 func (registry *inmemorySensorRegistry) ExistSensor(sensorId uuid.UUID) (bool, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
 	for _, s := range registry.weatherSensors {
 		if s.Id == sensorId {
 			return true, nil
@@ -58,27 +86,155 @@ func (registry *inmemorySensorRegistry) ExistSensor(sensorId uuid.UUID) (bool, e
 }
 
 func (registry *inmemorySensorRegistry) DeleteSensor(sensorId uuid.UUID) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
 	for i, s := range registry.weatherSensors {
 		if s.Id == sensorId {
 			registry.weatherSensors = remove(registry.weatherSensors, i)
+			registry.geoIndex.remove(sensorId)
 			return nil
 		}
 	}
-	return nil
+	return errors.New("no sensor could be deleted")
 }
 
 func (registry *inmemorySensorRegistry) UpdateSensor(sensor *WeatherSensor) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
 	for i, s := range registry.weatherSensors {
 		if s.Id == sensor.Id {
 			registry.weatherSensors[i] = sensor
+			registry.geoIndex.remove(sensor.Id)
+			registry.geoIndex.add(sensor)
+			return nil
 		}
 	}
 
-	return nil
+	return errors.New("no sensor could be updated")
 }
 
 func (registry *inmemorySensorRegistry) GetSensors() ([]*WeatherSensor, error) {
-	return registry.weatherSensors, nil
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	return append([]*WeatherSensor{}, registry.weatherSensors...), nil
+}
+
+func (registry *inmemorySensorRegistry) GetSensorsOfUser(userId string) ([]*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	result := make([]*WeatherSensor, 0)
+	for _, s := range registry.weatherSensors {
+		if s.UserId == userId {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (registry *inmemorySensorRegistry) GetSensorsOfOrganization(organizationId uuid.UUID) ([]*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	result := make([]*WeatherSensor, 0)
+	for _, s := range registry.weatherSensors {
+		if s.OrganizationId != nil && *s.OrganizationId == organizationId {
+			result = append(result, s)
+		}
+	}
+	return result, nil
+}
+
+func (registry *inmemorySensorRegistry) GetSensorsNear(latitude float64, longitude float64, radius float64) ([]*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	return registry.geoIndex.near(latitude, longitude, radius), nil
+}
+
+func (registry *inmemorySensorRegistry) GetSensorsInBox(box GeoBox) ([]*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	return registry.geoIndex.within(box), nil
+}
+
+func (registry *inmemorySensorRegistry) GetSensorByExternalId(protocol IngestProtocol, id string) (*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	for _, s := range registry.weatherSensors {
+		if externalId := s.ExternalId(protocol); externalId != nil && externalId.Id == id {
+			return s, nil
+		}
+	}
+	return nil, errors.New("sensor does not exist")
+}
+
+func (registry *inmemorySensorRegistry) SoftDeleteSensor(sensor *WeatherSensor) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for i, s := range registry.weatherSensors {
+		if s.Id == sensor.Id {
+			registry.weatherSensors = remove(registry.weatherSensors, i)
+			registry.geoIndex.remove(sensor.Id)
+			registry.deletedSensors = append(registry.deletedSensors, sensor)
+			return nil
+		}
+	}
+	return errors.New("no sensor could be deleted")
+}
+
+func (registry *inmemorySensorRegistry) GetDeletedSensor(sensorId uuid.UUID) (*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	for _, s := range registry.deletedSensors {
+		if s.Id == sensorId {
+			return s, nil
+		}
+	}
+	return nil, errors.New("deleted sensor does not exist")
+}
+
+func (registry *inmemorySensorRegistry) GetDeletedSensors() ([]*WeatherSensor, error) {
+	registry.mutex.RLock()
+	defer registry.mutex.RUnlock()
+
+	return append([]*WeatherSensor{}, registry.deletedSensors...), nil
+}
+
+func (registry *inmemorySensorRegistry) RestoreSensor(sensorId uuid.UUID) (*WeatherSensor, error) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for i, s := range registry.deletedSensors {
+		if s.Id == sensorId {
+			registry.deletedSensors = remove(registry.deletedSensors, i)
+			s.Deletion = nil
+			registry.weatherSensors = append(registry.weatherSensors, s)
+			registry.geoIndex.add(s)
+			return s, nil
+		}
+	}
+	return nil, errors.New("no sensor could be restored")
+}
+
+func (registry *inmemorySensorRegistry) PurgeSensor(sensorId uuid.UUID) error {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+
+	for i, s := range registry.deletedSensors {
+		if s.Id == sensorId {
+			registry.deletedSensors = remove(registry.deletedSensors, i)
+			return nil
+		}
+	}
+	return errors.New("no sensor could be purged")
 }
 
 func (registry *inmemorySensorRegistry) Close() error {
@@ -0,0 +1,163 @@
+package storage
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//LinePrecisions maps the precision parameter of the influxdb write api to the duration of one timestamp unit
+var LinePrecisions = map[string]time.Duration{
+	"":   time.Nanosecond,
+	"n":  time.Nanosecond,
+	"ns": time.Nanosecond,
+	"u":  time.Microsecond,
+	"us": time.Microsecond,
+	"ms": time.Millisecond,
+	"s":  time.Second,
+	"m":  time.Minute,
+	"h":  time.Hour,
+}
+
+//ParseLineProtocol parses lines of the influxdb line protocol to WeatherData, the sensor id is taken from the sensorTag
+//the field keys are the value types, string and boolean fields are ignored and the measurement name is not used
+//lines of the same sensor and timestamp are merged, lines without timestamp get the time now
+func ParseLineProtocol(body []byte, sensorTag string, precision time.Duration, now time.Time) ([]*WeatherData, error) {
+	result := make([]*WeatherData, 0)
+	merged := make(map[string]*WeatherData)
+
+	for number, line := range bytes.Split(body, []byte("\n")) {
+		line = bytes.TrimSpace(line)
+		if len(line) == 0 || line[0] == '#' {
+			continue
+		}
+
+		data, err := parseLine(string(line), sensorTag, precision, now)
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %v", number+1, err)
+		}
+		if len(data.Values) == 0 {
+			continue
+		}
+
+		key := data.SensorId.String() + strconv.FormatInt(data.TimeStamp.UnixNano(), 10)
+		if existing, exists := merged[key]; exists {
+			for valueType, value := range data.Values {
+				existing.Values[valueType] = value
+			}
+			continue
+		}
+		merged[key] = data
+		result = append(result, data)
+	}
+	return result, nil
+}
+
+func parseLine(line string, sensorTag string, precision time.Duration, now time.Time) (*WeatherData, error) {
+	sections := splitUnescaped(line, ' ', true)
+	if len(sections) < 2 || len(sections) > 3 {
+		return nil, errors.New("expected measurement, fields and optional timestamp")
+	}
+
+	data := NewWeatherData()
+	data.TimeStamp = now
+
+	sensorFound := false
+	for _, tag := range splitUnescaped(sections[0], ',', false)[1:] {
+		key, value, err := splitKeyValue(tag)
+		if err != nil {
+			return nil, err
+		}
+		if key == sensorTag {
+			if data.SensorId, err = uuid.Parse(value); err != nil {
+				return nil, fmt.Errorf("invalid sensor id %v", value)
+			}
+			sensorFound = true
+		}
+	}
+	if !sensorFound {
+		return nil, fmt.Errorf("missing tag %v", sensorTag)
+	}
+
+	for _, field := range splitUnescaped(sections[1], ',', true) {
+		key, value, err := splitKeyValue(field)
+		if err != nil {
+			return nil, err
+		}
+		number, isNumber, err := parseFieldValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("field %v: %v", key, err)
+		}
+		if isNumber {
+			data.Values[SensorValueType(key)] = number
+		}
+	}
+
+	if len(sections) == 3 {
+		timeStamp, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %v", sections[2])
+		}
+		data.TimeStamp = time.Unix(0, timeStamp*int64(precision))
+	}
+	return data, nil
+}
+
+//parseFieldValue parses a float, integer or unsigned field, the second result is false for string and boolean fields
+func parseFieldValue(value string) (float64, bool, error) {
+	if len(value) == 0 {
+		return 0, false, errors.New("missing value")
+	}
+	if value[0] == '"' {
+		return 0, false, nil
+	}
+	switch value {
+	case "t", "T", "true", "True", "TRUE", "f", "F", "false", "False", "FALSE":
+		return 0, false, nil
+	}
+	if last := value[len(value)-1]; last == 'i' || last == 'u' {
+		number, err := strconv.ParseInt(value[:len(value)-1], 10, 64)
+		return float64(number), err == nil, err
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	return number, err == nil, err
+}
+
+//splitKeyValue splits at the first unescaped equal sign, keys can't contain unescaped equal signs but quoted values can
+func splitKeyValue(pair string) (string, string, error) {
+	for i := 0; i < len(pair); i++ {
+		if pair[i] == '\\' {
+			i++
+		} else if pair[i] == '=' && i != 0 {
+			return unescape(pair[:i]), unescape(pair[i+1:]), nil
+		}
+	}
+	return "", "", fmt.Errorf("invalid key value pair %v", pair)
+}
+
+//splitUnescaped splits at the separator if it is not escaped by a backslash or, if quoted is set, within double quotes
+func splitUnescaped(value string, separator byte, quoted bool) []string {
+	parts := make([]string, 0)
+	start, inQuotes := 0, false
+	for i := 0; i < len(value); i++ {
+		switch {
+		case value[i] == '\\':
+			i++
+		case quoted && value[i] == '"':
+			inQuotes = !inQuotes
+		case value[i] == separator && !inQuotes:
+			parts = append(parts, value[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, value[start:])
+}
+
+func unescape(value string) string {
+	return strings.NewReplacer(`\,`, ",", `\ `, " ", `\=`, "=", `\"`, `"`, `\\`, `\`).Replace(value)
+}
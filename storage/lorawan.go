@@ -0,0 +1,347 @@
+package storage
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//PayloadDecoder decodes the raw payload of an uplink if the network server sent no decoded fields
+type PayloadDecoder string
+
+const (
+	NoDecoder         PayloadDecoder = "none"
+	CayenneLppDecoder PayloadDecoder = "cayennelpp"
+)
+
+//channelSuffix is appended by the cayenne lpp formatter of the network servers, e.g. temperature_1
+var channelSuffix = regexp.MustCompile(`_([0-9]+)$`)
+
+//decodedField maps a name of decoded payload fields to a value type, values are converted from the unit if given
+type decodedField struct {
+	name      string
+	valueType SensorValueType
+	unit      Unit
+}
+
+//decodedFields are the common names of decoded payload fields, ordered by priority within a value type
+//fields with other names are used as value type, the units are the ones of cayenne lpp
+var decodedFields = []decodedField{
+	{"temperature", Temperature, Celsius},
+	{"temp", Temperature, Celsius},
+	{"relative_humidity", Humidity, ""},
+	{"humidity", Humidity, ""},
+	{"barometric_pressure", Pressure, Hectopascal},
+	{"pressure", Pressure, Hectopascal},
+	{"co2", Co2Level, ""},
+	{"wind_speed", WindSpeed, MeterPerSecond},
+	{"wind_direction", WindDirection, ""},
+	{"pm2_5", Pm25, ""},
+}
+
+//cayenneLppUnits are the units of the values decoded from cayenne lpp
+var cayenneLppUnits = map[SensorValueType]Unit{
+	Temperature: Celsius,
+	Pressure:    Hectopascal,
+}
+
+//LorawanUplink is an uplink of a LoRaWAN device forwarded by the network server
+type LorawanUplink struct {
+	DevEui     string
+	Payload    []byte
+	Decoded    map[string]interface{}
+	ReceivedAt time.Time
+	Link       *LinkQuality
+}
+
+//ParseTtnUplink parses the uplink message webhook of The Things Stack v3
+func ParseTtnUplink(body []byte) (*LorawanUplink, error) {
+	var message struct {
+		EndDeviceIds struct {
+			DevEui string `json:"dev_eui"`
+		} `json:"end_device_ids"`
+		ReceivedAt    time.Time `json:"received_at"`
+		UplinkMessage *struct {
+			FCnt           int64                  `json:"f_cnt"`
+			FrmPayload     []byte                 `json:"frm_payload"`
+			DecodedPayload map[string]interface{} `json:"decoded_payload"`
+			RxMetadata     []struct {
+				GatewayIds struct {
+					GatewayId string `json:"gateway_id"`
+				} `json:"gateway_ids"`
+				Rssi float64 `json:"rssi"`
+				Snr  float64 `json:"snr"`
+			} `json:"rx_metadata"`
+			Settings struct {
+				DataRate struct {
+					Lora struct {
+						SpreadingFactor int `json:"spreading_factor"`
+					} `json:"lora"`
+				} `json:"data_rate"`
+			} `json:"settings"`
+		} `json:"uplink_message"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, err
+	}
+	if message.UplinkMessage == nil {
+		return nil, errors.New("not an uplink message")
+	}
+
+	uplink := &LorawanUplink{
+		DevEui:     NormalizeDevEui(message.EndDeviceIds.DevEui),
+		Payload:    message.UplinkMessage.FrmPayload,
+		Decoded:    message.UplinkMessage.DecodedPayload,
+		ReceivedAt: message.ReceivedAt,
+		Link: &LinkQuality{
+			FrameCounter:    message.UplinkMessage.FCnt,
+			SpreadingFactor: message.UplinkMessage.Settings.DataRate.Lora.SpreadingFactor,
+		},
+	}
+	for _, gateway := range message.UplinkMessage.RxMetadata {
+		uplink.Link.addGateway(gateway.GatewayIds.GatewayId, gateway.Rssi, gateway.Snr)
+	}
+	return uplink, nil
+}
+
+//ParseChirpstackUplink parses the up event of the http integration of ChirpStack v4 and v3
+func ParseChirpstackUplink(body []byte) (*LorawanUplink, error) {
+	var message struct {
+		Time       time.Time `json:"time"`
+		DeviceInfo *struct {
+			DevEui string `json:"devEui"`
+		} `json:"deviceInfo"`
+		DevEUI     string                 `json:"devEUI"`
+		FCnt       int64                  `json:"fCnt"`
+		Data       []byte                 `json:"data"`
+		Object     map[string]interface{} `json:"object"`
+		ObjectJSON string                 `json:"objectJSON"`
+		RxInfo     []struct {
+			GatewayId string  `json:"gatewayId"`
+			GatewayID string  `json:"gatewayID"`
+			Rssi      float64 `json:"rssi"`
+			Snr       float64 `json:"snr"`
+			LoRaSNR   float64 `json:"loRaSNR"`
+		} `json:"rxInfo"`
+		TxInfo struct {
+			Modulation struct {
+				Lora struct {
+					SpreadingFactor int `json:"spreadingFactor"`
+				} `json:"lora"`
+			} `json:"modulation"`
+			LoRaModulationInfo struct {
+				SpreadingFactor int `json:"spreadingFactor"`
+			} `json:"loRaModulationInfo"`
+		} `json:"txInfo"`
+	}
+	if err := json.Unmarshal(body, &message); err != nil {
+		return nil, err
+	}
+
+	uplink := &LorawanUplink{
+		Payload:    message.Data,
+		Decoded:    message.Object,
+		ReceivedAt: message.Time,
+		Link:       &LinkQuality{FrameCounter: message.FCnt},
+	}
+
+	//v4 sends the DevEUI as hex, v3 as hex or base64 depending on the marshaler
+	if message.DeviceInfo != nil {
+		uplink.DevEui = NormalizeDevEui(message.DeviceInfo.DevEui)
+	} else if devEui, err := base64.StdEncoding.DecodeString(message.DevEUI); err == nil && len(devEui) == 8 && len(message.DevEUI) != 16 {
+		uplink.DevEui = hex.EncodeToString(devEui)
+	} else {
+		uplink.DevEui = NormalizeDevEui(message.DevEUI)
+	}
+	if len(uplink.DevEui) == 0 {
+		return nil, errors.New("missing DevEUI")
+	}
+
+	if uplink.Decoded == nil && len(message.ObjectJSON) != 0 {
+		if err := json.Unmarshal([]byte(message.ObjectJSON), &uplink.Decoded); err != nil {
+			return nil, err
+		}
+	}
+
+	uplink.Link.SpreadingFactor = message.TxInfo.Modulation.Lora.SpreadingFactor
+	if uplink.Link.SpreadingFactor == 0 {
+		uplink.Link.SpreadingFactor = message.TxInfo.LoRaModulationInfo.SpreadingFactor
+	}
+	for _, gateway := range message.RxInfo {
+		gatewayId, snr := gateway.GatewayId, gateway.Snr
+		if len(gatewayId) == 0 {
+			gatewayId, snr = gateway.GatewayID, gateway.LoRaSNR
+		}
+		uplink.Link.addGateway(gatewayId, gateway.Rssi, snr)
+	}
+	return uplink, nil
+}
+
+//WeatherData returns the decoded fields of the uplink, the payload is decoded with the decoder if the network server sent no decoded fields
+//values are converted to the units of the catalog, values which can not be converted are ignored
+func (uplink *LorawanUplink) WeatherData(decoder PayloadDecoder, definitions []*ValueTypeDefinition) (*WeatherData, error) {
+	data := NewWeatherData()
+	data.TimeStamp = uplink.ReceivedAt
+	if data.TimeStamp.IsZero() {
+		data.TimeStamp = time.Now()
+	}
+
+	if len(uplink.Decoded) != 0 {
+		for _, field := range uplink.decodedFields() {
+			value := uplink.Decoded[field.name].(float64)
+			if len(field.unit) != 0 {
+				var err error
+				if value, err = ConvertToCatalogUnit(value, field.unit, field.valueType, definitions); err != nil {
+					continue
+				}
+			}
+			data.Values[field.valueType] = value
+		}
+		return data, nil
+	}
+
+	switch decoder {
+	case CayenneLppDecoder:
+		values, err := DecodeCayenneLpp(uplink.Payload)
+		if err != nil {
+			return nil, err
+		}
+		for valueType, value := range values {
+			if unit, exists := cayenneLppUnits[valueType]; exists {
+				if value, err = ConvertToCatalogUnit(value, unit, valueType, definitions); err != nil {
+					continue
+				}
+			}
+			data.Values[valueType] = value
+		}
+	case NoDecoder:
+	default:
+		return nil, fmt.Errorf("unknown payload decoder %v", decoder)
+	}
+	return data, nil
+}
+
+//decodedFields returns one numeric decoded field per value type, known names by their priority before other names and lower channels before higher ones
+func (uplink *LorawanUplink) decodedFields() []decodedField {
+	names := make([]string, 0, len(uplink.Decoded))
+	for name, value := range uplink.Decoded {
+		if _, isNumber := value.(float64); isNumber {
+			names = append(names, name)
+		}
+	}
+	sort.Slice(names, func(i, j int) bool {
+		iPriority, iChannel := decodedFieldPriority(names[i])
+		jPriority, jChannel := decodedFieldPriority(names[j])
+		if iPriority != jPriority {
+			return iPriority < jPriority
+		}
+		if iChannel != jChannel {
+			return iChannel < jChannel
+		}
+		return names[i] < names[j]
+	})
+
+	fields := make([]decodedField, 0, len(names))
+	used := make(map[SensorValueType]bool)
+	for _, name := range names {
+		field := decodedField{name, SensorValueType(channelSuffix.ReplaceAllString(name, "")), ""}
+		if priority, _ := decodedFieldPriority(name); priority < len(decodedFields) {
+			field.valueType, field.unit = decodedFields[priority].valueType, decodedFields[priority].unit
+		}
+		if !used[field.valueType] {
+			used[field.valueType] = true
+			fields = append(fields, field)
+		}
+	}
+	return fields
+}
+
+//decodedFieldPriority returns the position of the name without channel in decodedFields, len(decodedFields) for other names, and the channel, 0 without channel
+func decodedFieldPriority(name string) (int, int) {
+	channel := 0
+	if match := channelSuffix.FindStringSubmatch(name); match != nil {
+		channel, _ = strconv.Atoi(match[1])
+		name = strings.TrimSuffix(name, match[0])
+	}
+	for priority, field := range decodedFields {
+		if field.name == name {
+			return priority, channel
+		}
+	}
+	return len(decodedFields), channel
+}
+
+//NormalizeDevEui returns the DevEUI as lower case hex without separators
+func NormalizeDevEui(devEui string) string {
+	return strings.ToLower(strings.NewReplacer(":", "", "-", "", " ", "").Replace(devEui))
+}
+
+//cayenneLppType is a data type of the cayenne low power payload
+type cayenneLppType struct {
+	size      int
+	signed    bool
+	divisor   float64
+	valueType SensorValueType //empty for types without value type, which are skipped
+}
+
+//cayenneLppTypes are the types of cayenne lpp and the extended types for weather sensors
+var cayenneLppTypes = map[byte]cayenneLppType{
+	0:   {1, false, 1, ""},            //digital input
+	1:   {1, false, 1, ""},            //digital output
+	2:   {2, true, 100, ""},           //analog input
+	3:   {2, true, 100, ""},           //analog output
+	101: {2, false, 1, ""},            //illuminance
+	102: {1, false, 1, ""},            //presence
+	103: {2, true, 10, Temperature},   //temperature
+	104: {1, false, 2, Humidity},      //relative humidity
+	113: {6, true, 1000, ""},          //accelerometer
+	115: {2, false, 10, Pressure},     //barometer
+	116: {2, false, 100, ""},          //voltage
+	120: {1, false, 1, ""},            //percentage
+	125: {2, false, 1, Co2Level},      //concentration
+	134: {6, true, 100, ""},           //gyrometer
+	136: {9, true, 1, ""},             //gps location
+	190: {2, false, 1, WindDirection}, //direction
+}
+
+//DecodeCayenneLpp decodes a cayenne low power payload, the first channel of each type is used
+func DecodeCayenneLpp(payload []byte) (map[SensorValueType]float64, error) {
+	values := make(map[SensorValueType]float64)
+	for i := 0; i < len(payload); {
+		if i+2 > len(payload) {
+			return nil, errors.New("truncated cayenne lpp payload")
+		}
+		lppType, known := cayenneLppTypes[payload[i+1]]
+		if !known {
+			return nil, fmt.Errorf("unknown cayenne lpp type %d", payload[i+1])
+		}
+		start, end := i+2, i+2+lppType.size
+		if end > len(payload) {
+			return nil, errors.New("truncated cayenne lpp payload")
+		}
+		i = end
+
+		if len(lppType.valueType) == 0 {
+			continue
+		}
+		if _, exists := values[lppType.valueType]; exists {
+			continue
+		}
+		var raw int64
+		for _, b := range payload[start:end] {
+			raw = raw<<8 | int64(b)
+		}
+		if lppType.signed && payload[start]&0x80 != 0 {
+			raw -= 1 << (8 * uint(lppType.size))
+		}
+		values[lppType.valueType] = float64(raw) / lppType.divisor
+	}
+	return values, nil
+}
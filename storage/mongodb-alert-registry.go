@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongodbAlertRegistry struct {
+	ruleCollection  *mongo.Collection
+	alertCollection *mongo.Collection
+	client          *mongo.Client
+}
+
+//NewMongodbAlertRegistry Factory
+func NewMongodbAlertRegistry(mongoCfg config.MongoConfig) (*mongodbAlertRegistry, error) {
+	alertRegistry := new(mongodbAlertRegistry)
+
+	client, err := newMongodbClient(mongoCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	alertRegistry.client = client
+	alertRegistry.ruleCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.AlertRuleCollection)
+	alertRegistry.alertCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.AlertCollection)
+
+	_, err = alertRegistry.ruleCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"sensorid": 1}},
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	_, err = alertRegistry.alertCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "sensorid", Value: 1}, {Key: "state", Value: 1}}},
+		{Keys: bson.M{"firedat": -1}},
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return alertRegistry, nil
+}
+
+func (registry *mongodbAlertRegistry) AddRule(rule *AlertRule) (*AlertRule, error) {
+	rule.Id = uuid.New()
+	_, err := registry.ruleCollection.InsertOne(context.Background(), rule)
+
+	return rule, err
+}
+
+func (registry *mongodbAlertRegistry) GetRule(ruleId uuid.UUID) (*AlertRule, error) {
+	rule := new(AlertRule)
+	err := registry.ruleCollection.FindOne(context.Background(), bson.M{"id": ruleId}).Decode(rule)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("alert rule does not exist")
+	}
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	return rule, nil
+}
+
+func (registry *mongodbAlertRegistry) GetRulesOfSensor(sensorId uuid.UUID) ([]*AlertRule, error) {
+	cursor, err := registry.ruleCollection.Find(context.Background(), bson.M{"sensorid": sensorId})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*AlertRule = make([]*AlertRule, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbAlertRegistry) UpdateRule(rule *AlertRule) error {
+	res, err := registry.ruleCollection.ReplaceOne(
+		context.Background(),
+		bson.M{"id": rule.Id},
+		rule)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("no alert rule could be updated")
+	}
+	return nil
+}
+
+func (registry *mongodbAlertRegistry) DeleteRule(ruleId uuid.UUID) error {
+	res, err := registry.ruleCollection.DeleteOne(context.Background(), bson.M{"id": ruleId})
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("no alert rule could be deleted")
+	}
+	return nil
+}
+
+//SaveAlert inserts a new alert or replaces an existing one
+func (registry *mongodbAlertRegistry) SaveAlert(alert *Alert) error {
+	_, err := registry.alertCollection.ReplaceOne(
+		context.Background(),
+		bson.M{"id": alert.Id},
+		alert,
+		options.Replace().SetUpsert(true))
+	if err != nil {
+		log.Print(err)
+	}
+	return err
+}
+
+func (registry *mongodbAlertRegistry) GetOpenAlerts(sensorIds []uuid.UUID) ([]*Alert, error) {
+	return registry.findAlerts(bson.M{"sensorid": bson.M{"$in": sensorIds}, "state": Firing})
+}
+
+func (registry *mongodbAlertRegistry) GetOpenAlertsOfKind(kind AlertKind) ([]*Alert, error) {
+	return registry.findAlerts(bson.M{"kind": kind, "state": Firing})
+}
+
+func (registry *mongodbAlertRegistry) GetAlertsOfSensor(sensorId uuid.UUID) ([]*Alert, error) {
+	return registry.findAlerts(bson.M{"sensorid": sensorId})
+}
+
+func (registry *mongodbAlertRegistry) findAlerts(filter bson.M) ([]*Alert, error) {
+	cursor, err := registry.alertCollection.Find(context.Background(), filter, options.Find().SetSort(bson.M{"firedat": -1}))
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*Alert = make([]*Alert, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbAlertRegistry) Close() error {
+	return registry.client.Disconnect(context.Background())
+}
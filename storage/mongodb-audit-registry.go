@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"context"
+	"log"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongodbAuditRegistry struct {
+	collection *mongo.Collection
+	client     *mongo.Client
+}
+
+//NewMongodbAuditRegistry Factory
+func NewMongodbAuditRegistry(mongoCfg config.MongoConfig) (*mongodbAuditRegistry, error) {
+	auditRegistry := new(mongodbAuditRegistry)
+
+	client, err := newMongodbClient(mongoCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	auditRegistry.client = client
+	auditRegistry.collection = client.Database(mongoCfg.Database).Collection(mongoCfg.AuditCollection)
+
+	_, err = auditRegistry.collection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.D{{Key: "sensorid", Value: 1}, {Key: "changedat", Value: -1}}},
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return auditRegistry, nil
+}
+
+func (registry *mongodbAuditRegistry) AddEntry(entry *AuditEntry) (*AuditEntry, error) {
+	entry.Id = uuid.New()
+	_, err := registry.collection.InsertOne(context.Background(), entry)
+	if err != nil {
+		log.Print(err)
+	}
+	return entry, err
+}
+
+//GetEntriesOfSensor returns the entries of the sensor, latest first
+func (registry *mongodbAuditRegistry) GetEntriesOfSensor(sensorId uuid.UUID) ([]*AuditEntry, error) {
+	cursor, err := registry.collection.Find(context.Background(), bson.M{"sensorid": sensorId}, options.Find().SetSort(bson.M{"changedat": -1}))
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*AuditEntry = make([]*AuditEntry, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbAuditRegistry) Close() error {
+	return registry.client.Disconnect(context.Background())
+}
@@ -0,0 +1,117 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongodbOrganizationRegistry struct {
+	organizationCollection *mongo.Collection
+	client                 *mongo.Client
+}
+
+//NewMongodbOrganizationRegistry Factory
+func NewMongodbOrganizationRegistry(mongoCfg config.MongoConfig) (*mongodbOrganizationRegistry, error) {
+	organizationRegistry := new(mongodbOrganizationRegistry)
+
+	client, err := newMongodbClient(mongoCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	organizationRegistry.client = client
+	organizationRegistry.organizationCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.OrganizationCollection)
+
+	_, err = organizationRegistry.organizationCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"members.userid": 1}},
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return organizationRegistry, nil
+}
+
+func (registry *mongodbOrganizationRegistry) CreateOrganization(organization *Organization) (*Organization, error) {
+	organization.Id = uuid.New()
+	_, err := registry.organizationCollection.InsertOne(context.Background(), organization)
+
+	return organization, err
+}
+
+func (registry *mongodbOrganizationRegistry) GetOrganization(organizationId uuid.UUID) (*Organization, error) {
+	organization := new(Organization)
+	err := registry.organizationCollection.FindOne(context.Background(), bson.M{"id": organizationId}).Decode(organization)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("organization does not exist")
+	}
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	return organization, nil
+}
+
+func (registry *mongodbOrganizationRegistry) GetOrganizations() ([]*Organization, error) {
+	return registry.findOrganizations(bson.M{})
+}
+
+func (registry *mongodbOrganizationRegistry) GetOrganizationsOfUser(userId string) ([]*Organization, error) {
+	return registry.findOrganizations(bson.M{"members.userid": userId})
+}
+
+func (registry *mongodbOrganizationRegistry) findOrganizations(filter bson.M) ([]*Organization, error) {
+	cursor, err := registry.organizationCollection.Find(context.Background(), filter)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*Organization = make([]*Organization, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbOrganizationRegistry) UpdateOrganization(organization *Organization) error {
+	res, err := registry.organizationCollection.ReplaceOne(
+		context.Background(),
+		bson.M{"id": organization.Id},
+		organization)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("no organization could be updated")
+	}
+	return nil
+}
+
+func (registry *mongodbOrganizationRegistry) DeleteOrganization(organizationId uuid.UUID) error {
+	res, err := registry.organizationCollection.DeleteOne(context.Background(), bson.M{"id": organizationId})
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("no organization could be deleted")
+	}
+	return nil
+}
+
+func (registry *mongodbOrganizationRegistry) Close() error {
+	return registry.client.Disconnect(context.Background())
+}
@@ -0,0 +1,214 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"sync"
+	"time"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongodbSensorStatusRegistry struct {
+	statusCollection *mongo.Collection
+	client           *mongo.Client
+	//statuses caches the recorded statuses, so recording does not need to read from mongodb
+	statuses map[uuid.UUID]*SensorStatus
+	//dirty are the cached statuses changed since the last flush
+	dirty map[uuid.UUID]bool
+	mutex sync.Mutex
+	stop  chan struct{}
+	done  chan struct{}
+}
+
+//NewMongodbSensorStatusRegistry Factory
+func NewMongodbSensorStatusRegistry(mongoCfg config.MongoConfig) (*mongodbSensorStatusRegistry, error) {
+	statusRegistry := new(mongodbSensorStatusRegistry)
+	statusRegistry.statuses = make(map[uuid.UUID]*SensorStatus)
+	statusRegistry.dirty = make(map[uuid.UUID]bool)
+
+	client, err := newMongodbClient(mongoCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	statusRegistry.client = client
+	statusRegistry.statusCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.SensorStatusCollection)
+
+	_, err = statusRegistry.statusCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys: bson.M{"sensorid": 1}, Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	statusRegistry.stop = make(chan struct{})
+	statusRegistry.done = make(chan struct{})
+	go statusRegistry.flushPeriodically(config.SensorStatusFlushInterval)
+
+	return statusRegistry, nil
+}
+
+//RecordData updates the cached status, it is written to mongodb with the next flush
+func (registry *mongodbSensorStatusRegistry) RecordData(data *WeatherData) error {
+	now := time.Now()
+	return registry.update(data.SensorId, func(status *SensorStatus) {
+		status.Record(data, now)
+	})
+}
+
+//RecordLinkQuality updates the cached status, it is written to mongodb with the next flush
+func (registry *mongodbSensorStatusRegistry) RecordLinkQuality(sensorId uuid.UUID, link *LinkQuality) error {
+	return registry.update(sensorId, func(status *SensorStatus) {
+		status.Link = link
+	})
+}
+
+//update changes the cached status of a sensor and marks it for the next flush
+//a status that is not cached yet is read from mongodb without holding the lock
+func (registry *mongodbSensorStatusRegistry) update(sensorId uuid.UUID, change func(status *SensorStatus)) error {
+	registry.mutex.Lock()
+	_, exists := registry.statuses[sensorId]
+	registry.mutex.Unlock()
+
+	var loaded *SensorStatus
+	if !exists {
+		var err error
+		if loaded, err = registry.findStatus(sensorId); err != nil {
+			loaded = NewSensorStatus(sensorId)
+		}
+	}
+
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	status, exists := registry.statuses[sensorId]
+	if !exists {
+		status = loaded
+		registry.statuses[sensorId] = status
+	}
+	change(status)
+	registry.dirty[sensorId] = true
+	return nil
+}
+
+func (registry *mongodbSensorStatusRegistry) flushPeriodically(interval time.Duration) {
+	defer close(registry.done)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			registry.flush()
+		case <-registry.stop:
+			return
+		}
+	}
+}
+
+//flush writes the changed statuses in one bulk write, failed statuses are written again with the next flush
+func (registry *mongodbSensorStatusRegistry) flush() error {
+	registry.mutex.Lock()
+	sensorIds := make([]uuid.UUID, 0, len(registry.dirty))
+	models := make([]mongo.WriteModel, 0, len(registry.dirty))
+	for sensorId := range registry.dirty {
+		sensorIds = append(sensorIds, sensorId)
+		models = append(models, mongo.NewReplaceOneModel().
+			SetFilter(bson.M{"sensorid": sensorId}).
+			SetReplacement(registry.statuses[sensorId].copy()).
+			SetUpsert(true))
+	}
+	registry.dirty = make(map[uuid.UUID]bool)
+	registry.mutex.Unlock()
+
+	if len(models) == 0 {
+		return nil
+	}
+	_, err := registry.statusCollection.BulkWrite(context.Background(), models, options.BulkWrite().SetOrdered(false))
+	if err != nil {
+		log.Print(err)
+		registry.mutex.Lock()
+		for _, sensorId := range sensorIds {
+			registry.dirty[sensorId] = true
+		}
+		registry.mutex.Unlock()
+	}
+	return err
+}
+
+//cached returns a copy of the cached status of a sensor, it is newer than the stored status until it is flushed
+func (registry *mongodbSensorStatusRegistry) cached(sensorId uuid.UUID) (*SensorStatus, bool) {
+	registry.mutex.Lock()
+	defer registry.mutex.Unlock()
+	status, exists := registry.statuses[sensorId]
+	if !exists {
+		return nil, false
+	}
+	return status.copy(), true
+}
+
+func (registry *mongodbSensorStatusRegistry) GetStatus(sensorId uuid.UUID) (*SensorStatus, error) {
+	if status, exists := registry.cached(sensorId); exists {
+		return status, nil
+	}
+	return registry.findStatus(sensorId)
+}
+
+func (registry *mongodbSensorStatusRegistry) GetStatuses(sensorIds []uuid.UUID) ([]*SensorStatus, error) {
+	cursor, err := registry.statusCollection.Find(context.Background(), bson.M{"sensorid": bson.M{"$in": sensorIds}})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*SensorStatus = make([]*SensorStatus, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	stored := make(map[uuid.UUID]int, len(readData))
+	for i, status := range readData {
+		stored[status.SensorId] = i
+	}
+	for _, sensorId := range sensorIds {
+		status, exists := registry.cached(sensorId)
+		if !exists {
+			continue
+		}
+		if i, ok := stored[sensorId]; ok {
+			readData[i] = status
+		} else {
+			stored[sensorId] = len(readData)
+			readData = append(readData, status)
+		}
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbSensorStatusRegistry) findStatus(sensorId uuid.UUID) (*SensorStatus, error) {
+	status := new(SensorStatus)
+	err := registry.statusCollection.FindOne(context.Background(), bson.M{"sensorid": sensorId}).Decode(status)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("sensor has no status")
+	}
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	return status, nil
+}
+
+//Close writes the pending statuses before disconnecting
+func (registry *mongodbSensorStatusRegistry) Close() error {
+	close(registry.stop)
+	<-registry.done
+	registry.flush()
+	return registry.client.Disconnect(context.Background())
+}
@@ -0,0 +1,123 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongodbStationRegistry struct {
+	stationCollection *mongo.Collection
+	client            *mongo.Client
+}
+
+//NewMongodbStationRegistry Factory
+func NewMongodbStationRegistry(mongoCfg config.MongoConfig) (*mongodbStationRegistry, error) {
+	stationRegistry := new(mongodbStationRegistry)
+
+	client, err := newMongodbClient(mongoCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	stationRegistry.client = client
+	stationRegistry.stationCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.StationCollection)
+
+	_, err = stationRegistry.stationCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"userid": 1}},
+		{Keys: bson.M{"organizationid": 1}},
+		{Keys: bson.M{"sensorids": 1}},
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return stationRegistry, nil
+}
+
+func (registry *mongodbStationRegistry) RegisterStation(station *Station) (*Station, error) {
+	station.Id = uuid.New()
+	_, err := registry.stationCollection.InsertOne(context.Background(), station)
+
+	return station, err
+}
+
+func (registry *mongodbStationRegistry) GetStation(stationId uuid.UUID) (*Station, error) {
+	station := new(Station)
+	err := registry.stationCollection.FindOne(context.Background(), bson.M{"id": stationId}).Decode(station)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("station does not exist")
+	}
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	return station, nil
+}
+
+func (registry *mongodbStationRegistry) GetStationsOfUser(userId string) ([]*Station, error) {
+	return registry.findStations(bson.M{"userid": userId})
+}
+
+func (registry *mongodbStationRegistry) GetStationsOfOrganization(organizationId uuid.UUID) ([]*Station, error) {
+	return registry.findStations(bson.M{"organizationid": organizationId})
+}
+
+func (registry *mongodbStationRegistry) GetStationsOfSensor(sensorId uuid.UUID) ([]*Station, error) {
+	return registry.findStations(bson.M{"sensorids": sensorId})
+}
+
+func (registry *mongodbStationRegistry) findStations(filter bson.M) ([]*Station, error) {
+	cursor, err := registry.stationCollection.Find(context.Background(), filter)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*Station = make([]*Station, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbStationRegistry) UpdateStation(station *Station) error {
+	res, err := registry.stationCollection.ReplaceOne(
+		context.Background(),
+		bson.M{"id": station.Id},
+		station)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("no station could be updated")
+	}
+	return nil
+}
+
+func (registry *mongodbStationRegistry) DeleteStation(stationId uuid.UUID) error {
+	res, err := registry.stationCollection.DeleteOne(context.Background(), bson.M{"id": stationId})
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("no station could be deleted")
+	}
+	return nil
+}
+
+func (registry *mongodbStationRegistry) Close() error {
+	return registry.client.Disconnect(context.Background())
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"log"
+	"time"
 	"weather-data/config"
 
 	"github.com/google/uuid"
@@ -14,44 +15,94 @@ import (
 )
 
 type mongodbSensorRegistry struct {
-	sensorCollection *mongo.Collection
-	client           *mongo.Client
+	sensorCollection        *mongo.Collection
+	deletedSensorCollection *mongo.Collection
+	client                  *mongo.Client
 }
 
 func NewMongodbSensorRegistry(mongoCfg config.MongoConfig) (*mongodbSensorRegistry, error) {
 	sensorRegistry := new(mongodbSensorRegistry)
 
-	options := options.Client().ApplyURI(mongoCfg.Host).SetAuth(options.Credential{Username: mongoCfg.Username, Password: mongoCfg.Password})
-
-	client, err := mongo.NewClient(options)
+	client, err := newMongodbClient(mongoCfg)
 	if err != nil {
 		return nil, err
 	}
 
 	sensorRegistry.client = client
 
-	err = client.Connect(context.Background())
+	weathersensorsDB := client.Database(mongoCfg.Database)
+	sensorRegistry.sensorCollection = weathersensorsDB.Collection(mongoCfg.Collection)
+	sensorRegistry.deletedSensorCollection = weathersensorsDB.Collection(mongoCfg.DeletedSensorCollection)
+
+	_, err = sensorRegistry.sensorCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"userid": 1}},
+		{Keys: bson.M{"organizationid": 1}},
+		{Keys: bson.M{"name": 1}},
+		{Keys: bson.M{"tags": 1}},
+		{Keys: bson.M{"indoor": 1}},
+		{Keys: bson.D{{Key: "externalids.protocol", Value: 1}, {Key: "externalids.id", Value: 1}}},
+		{Keys: bson.M{"position": "2dsphere"}},
+	})
 	if err != nil {
 		log.Print(err)
 		return nil, err
 	}
 
-	err = client.Ping(context.Background(), readpref.Primary())
+	_, err = sensorRegistry.deletedSensorCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"deletion.purgeat": 1}},
+	})
 	if err != nil {
 		log.Print(err)
 		return nil, err
 	}
 
-	weathersensorsDB := client.Database(mongoCfg.Database)
-	sensorRegistry.sensorCollection = weathersensorsDB.Collection(mongoCfg.Collection)
+	//sensors registered before the geospatial index existed get their position from longitude and latitude
+	_, err = sensorRegistry.sensorCollection.UpdateMany(context.Background(),
+		bson.M{"position": bson.M{"$exists": false}},
+		mongo.Pipeline{{{Key: "$set", Value: bson.M{"position": bson.M{
+			"type":        "Point",
+			"coordinates": bson.A{"$longitude", "$latitude"},
+		}}}}})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
 
 	log.Print("successfully created mongodb connection")
 
 	return sensorRegistry, nil
 }
 
+//newMongodbClient creates a new connected mongodb client
+func newMongodbClient(mongoCfg config.MongoConfig) (*mongo.Client, error) {
+	options := options.Client().ApplyURI(mongoCfg.Host).SetAuth(options.Credential{Username: mongoCfg.Username, Password: mongoCfg.Password})
+
+	client, err := mongo.NewClient(options)
+	if err != nil {
+		return nil, err
+	}
+
+	err = client.Connect(context.Background())
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	err = client.Ping(context.Background(), readpref.Primary())
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return client, nil
+}
+
 func (registry *mongodbSensorRegistry) RegisterSensor(sensor *WeatherSensor) (*WeatherSensor, error) {
 	sensor.Id = uuid.New()
+	sensor.CreatedAt = time.Now().UTC()
+	sensor.Position = NewGeoPoint(sensor.Longitude, sensor.Latitude)
 	_, err := registry.sensorCollection.InsertOne(context.Background(), sensor)
 
 	return sensor, err
@@ -128,6 +179,53 @@ func (registry *mongodbSensorRegistry) GetSensorsOfUser(userId string) ([]*Weath
 	return readData, nil
 }
 
+func (registry *mongodbSensorRegistry) GetSensorsOfOrganization(organizationId uuid.UUID) ([]*WeatherSensor, error) {
+	return registry.findSensors(bson.M{"organizationid": organizationId})
+}
+
+func (registry *mongodbSensorRegistry) GetSensorsNear(latitude float64, longitude float64, radius float64) ([]*WeatherSensor, error) {
+	return registry.findSensors(bson.M{"position": bson.M{"$nearSphere": bson.M{
+		"$geometry":    NewGeoPoint(longitude, latitude),
+		"$maxDistance": radius,
+	}}})
+}
+
+//GetSensorsInBox uses flat box semantics like GeoBox.Contains, a geodesic polygon would bend its edges and degenerate for wide boxes
+func (registry *mongodbSensorRegistry) GetSensorsInBox(box GeoBox) ([]*WeatherSensor, error) {
+	//GeoJSON stores the longitude before the latitude
+	return registry.findSensors(bson.M{
+		"position.coordinates.0": bson.M{"$gte": box.MinLongitude, "$lte": box.MaxLongitude},
+		"position.coordinates.1": bson.M{"$gte": box.MinLatitude, "$lte": box.MaxLatitude},
+	})
+}
+
+func (registry *mongodbSensorRegistry) GetSensorByExternalId(protocol IngestProtocol, id string) (*WeatherSensor, error) {
+	sensors, err := registry.findSensors(bson.M{"externalids": bson.M{"$elemMatch": bson.M{"protocol": protocol, "id": id}}})
+	if err != nil {
+		return nil, err
+	}
+	if len(sensors) == 0 {
+		return nil, errors.New("sensor does not exist")
+	}
+	return sensors[0], nil
+}
+
+func (registry *mongodbSensorRegistry) findSensors(filter bson.M) ([]*WeatherSensor, error) {
+	cursor, err := registry.sensorCollection.Find(context.Background(), filter)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*WeatherSensor = make([]*WeatherSensor, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
 func (registry *mongodbSensorRegistry) DeleteSensor(sensorId uuid.UUID) error {
 	res, err := registry.sensorCollection.DeleteOne(context.Background(), bson.M{"id": sensorId})
 	if err != nil {
@@ -142,6 +240,7 @@ func (registry *mongodbSensorRegistry) DeleteSensor(sensorId uuid.UUID) error {
 }
 
 func (registry *mongodbSensorRegistry) UpdateSensor(sensor *WeatherSensor) error {
+	sensor.Position = NewGeoPoint(sensor.Longitude, sensor.Latitude)
 	res, err := registry.sensorCollection.ReplaceOne(
 		context.Background(),
 		bson.M{"id": sensor.Id},
@@ -150,12 +249,84 @@ func (registry *mongodbSensorRegistry) UpdateSensor(sensor *WeatherSensor) error
 		log.Print(err)
 		return err
 	}
-	if res.MatchedCount == 0 || res.ModifiedCount == 0 {
+	if res.MatchedCount == 0 {
 		return errors.New("no sensor could be updated")
 	}
 	return nil
 }
 
+//SoftDeleteSensor moves the sensor to the collection of deleted sensors
+func (registry *mongodbSensorRegistry) SoftDeleteSensor(sensor *WeatherSensor) error {
+	if _, err := registry.deletedSensorCollection.InsertOne(context.Background(), sensor); err != nil {
+		log.Print(err)
+		return err
+	}
+	if err := registry.DeleteSensor(sensor.Id); err != nil {
+		registry.deletedSensorCollection.DeleteOne(context.Background(), bson.M{"id": sensor.Id})
+		return err
+	}
+	return nil
+}
+
+func (registry *mongodbSensorRegistry) GetDeletedSensor(sensorId uuid.UUID) (*WeatherSensor, error) {
+	sensor := new(WeatherSensor)
+	err := registry.deletedSensorCollection.FindOne(context.Background(), bson.M{"id": sensorId}).Decode(sensor)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("deleted sensor does not exist")
+	}
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	return sensor, nil
+}
+
+func (registry *mongodbSensorRegistry) GetDeletedSensors() ([]*WeatherSensor, error) {
+	cursor, err := registry.deletedSensorCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*WeatherSensor = make([]*WeatherSensor, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+//RestoreSensor moves the deleted sensor back to the collection of sensors
+func (registry *mongodbSensorRegistry) RestoreSensor(sensorId uuid.UUID) (*WeatherSensor, error) {
+	sensor, err := registry.GetDeletedSensor(sensorId)
+	if err != nil {
+		return nil, err
+	}
+
+	sensor.Deletion = nil
+	if _, err := registry.sensorCollection.InsertOne(context.Background(), sensor); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	if err := registry.PurgeSensor(sensorId); err != nil {
+		return nil, err
+	}
+	return sensor, nil
+}
+
+func (registry *mongodbSensorRegistry) PurgeSensor(sensorId uuid.UUID) error {
+	res, err := registry.deletedSensorCollection.DeleteOne(context.Background(), bson.M{"id": sensorId})
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("no sensor could be purged")
+	}
+	return nil
+}
+
 func (registry *mongodbSensorRegistry) Close() error {
 	err := registry.client.Disconnect(context.Background())
 	return err
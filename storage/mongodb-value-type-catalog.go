@@ -0,0 +1,164 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"weather-data/config"
+
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongodbValueTypeCatalog struct {
+	valueTypeCollection *mongo.Collection
+	seedCollection      *mongo.Collection
+	client              *mongo.Client
+}
+
+//valueTypeSeed marks a default value type as added to the catalog
+type valueTypeSeed struct {
+	Name SensorValueType
+}
+
+//NewMongodbValueTypeCatalog Factory, DefaultValueTypes are added to the catalog once
+func NewMongodbValueTypeCatalog(mongoCfg config.MongoConfig) (*mongodbValueTypeCatalog, error) {
+	catalog := new(mongodbValueTypeCatalog)
+
+	client, err := newMongodbClient(mongoCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	catalog.client = client
+	catalog.valueTypeCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.ValueTypeCollection)
+	catalog.seedCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.ValueTypeSeedCollection)
+
+	_, err = catalog.valueTypeCollection.Indexes().CreateOne(context.Background(), mongo.IndexModel{
+		Keys:    bson.M{"name": 1},
+		Options: options.Index().SetUnique(true),
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	if err = catalog.seedDefaultValueTypes(); err != nil {
+		return nil, err
+	}
+
+	return catalog, nil
+}
+
+//seedDefaultValueTypes adds each default value type once by name, so new defaults reach existing catalogs
+//the seeds are recorded, so changed definitions are kept and default value types deleted by an admin are not added again
+func (catalog *mongodbValueTypeCatalog) seedDefaultValueTypes() error {
+	for _, definition := range DefaultValueTypes() {
+		seeded, err := catalog.seedCollection.CountDocuments(context.Background(), bson.M{"name": definition.Name})
+		if err != nil {
+			log.Print(err)
+			return err
+		}
+		if seeded != 0 {
+			continue
+		}
+
+		res, err := catalog.valueTypeCollection.UpdateOne(
+			context.Background(),
+			bson.M{"name": definition.Name},
+			bson.M{"$setOnInsert": definition},
+			options.Update().SetUpsert(true))
+		if err != nil {
+			log.Print(err)
+			return err
+		}
+		if res.UpsertedCount != 0 {
+			log.Printf("added default value type %v to the catalog", definition.Name)
+		}
+
+		if _, err = catalog.seedCollection.InsertOne(context.Background(), valueTypeSeed{Name: definition.Name}); err != nil {
+			log.Print(err)
+			return err
+		}
+	}
+	return nil
+}
+
+func (catalog *mongodbValueTypeCatalog) AddValueType(definition *ValueTypeDefinition) (*ValueTypeDefinition, error) {
+	if err := definition.Validate(); err != nil {
+		return nil, err
+	}
+
+	_, err := catalog.valueTypeCollection.InsertOne(context.Background(), definition)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return definition, nil
+}
+
+func (catalog *mongodbValueTypeCatalog) GetValueType(name SensorValueType) (*ValueTypeDefinition, error) {
+	definition := new(ValueTypeDefinition)
+	err := catalog.valueTypeCollection.FindOne(context.Background(), bson.M{"name": name}).Decode(definition)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("value type does not exist")
+	}
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	return definition, nil
+}
+
+func (catalog *mongodbValueTypeCatalog) GetValueTypes() ([]*ValueTypeDefinition, error) {
+	cursor, err := catalog.valueTypeCollection.Find(context.Background(), bson.M{})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*ValueTypeDefinition = make([]*ValueTypeDefinition, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (catalog *mongodbValueTypeCatalog) UpdateValueType(definition *ValueTypeDefinition) error {
+	if err := definition.Validate(); err != nil {
+		return err
+	}
+
+	res, err := catalog.valueTypeCollection.ReplaceOne(
+		context.Background(),
+		bson.M{"name": definition.Name},
+		definition)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("no value type could be updated")
+	}
+	return nil
+}
+
+func (catalog *mongodbValueTypeCatalog) DeleteValueType(name SensorValueType) error {
+	res, err := catalog.valueTypeCollection.DeleteOne(context.Background(), bson.M{"name": name})
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("no value type could be deleted")
+	}
+	return nil
+}
+
+func (catalog *mongodbValueTypeCatalog) Close() error {
+	return catalog.client.Disconnect(context.Background())
+}
@@ -0,0 +1,162 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"log"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+	"go.mongodb.org/mongo-driver/bson"
+	"go.mongodb.org/mongo-driver/mongo"
+	"go.mongodb.org/mongo-driver/mongo/options"
+)
+
+type mongodbWebhookRegistry struct {
+	subscriptionCollection *mongo.Collection
+	deliveryCollection     *mongo.Collection
+	client                 *mongo.Client
+}
+
+//NewMongodbWebhookRegistry Factory, the delivery log is removed after the configured retention
+func NewMongodbWebhookRegistry(mongoCfg config.MongoConfig, deliveryRetention int32) (*mongodbWebhookRegistry, error) {
+	webhookRegistry := new(mongodbWebhookRegistry)
+
+	client, err := newMongodbClient(mongoCfg)
+	if err != nil {
+		return nil, err
+	}
+
+	webhookRegistry.client = client
+	webhookRegistry.subscriptionCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.WebhookCollection)
+	webhookRegistry.deliveryCollection = client.Database(mongoCfg.Database).Collection(mongoCfg.WebhookDeliveryCollection)
+
+	_, err = webhookRegistry.subscriptionCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.M{"id": 1}, Options: options.Index().SetUnique(true)},
+		{Keys: bson.M{"userid": 1}},
+		{Keys: bson.M{"sensorids": 1}},
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	_, err = webhookRegistry.deliveryCollection.Indexes().CreateMany(context.Background(), []mongo.IndexModel{
+		{Keys: bson.D{{Key: "subscriptionid", Value: 1}, {Key: "createdat", Value: -1}}},
+		{Keys: bson.M{"createdat": 1}, Options: options.Index().SetExpireAfterSeconds(deliveryRetention)},
+	})
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return webhookRegistry, nil
+}
+
+func (registry *mongodbWebhookRegistry) AddSubscription(subscription *WebhookSubscription) (*WebhookSubscription, error) {
+	subscription.Id = uuid.New()
+	_, err := registry.subscriptionCollection.InsertOne(context.Background(), subscription)
+
+	return subscription, err
+}
+
+func (registry *mongodbWebhookRegistry) GetSubscription(subscriptionId uuid.UUID) (*WebhookSubscription, error) {
+	subscription := new(WebhookSubscription)
+	err := registry.subscriptionCollection.FindOne(context.Background(), bson.M{"id": subscriptionId}).Decode(subscription)
+	if err == mongo.ErrNoDocuments {
+		return nil, errors.New("webhook subscription does not exist")
+	}
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+	return subscription, nil
+}
+
+func (registry *mongodbWebhookRegistry) GetSubscriptionsOfUser(userId string) ([]*WebhookSubscription, error) {
+	return registry.findSubscriptions(bson.M{"userid": userId})
+}
+
+func (registry *mongodbWebhookRegistry) GetSubscriptionsOfSensor(sensorId uuid.UUID) ([]*WebhookSubscription, error) {
+	return registry.findSubscriptions(bson.M{"sensorids": sensorId})
+}
+
+func (registry *mongodbWebhookRegistry) findSubscriptions(filter bson.M) ([]*WebhookSubscription, error) {
+	cursor, err := registry.subscriptionCollection.Find(context.Background(), filter)
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*WebhookSubscription = make([]*WebhookSubscription, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbWebhookRegistry) UpdateSubscription(subscription *WebhookSubscription) error {
+	res, err := registry.subscriptionCollection.ReplaceOne(
+		context.Background(),
+		bson.M{"id": subscription.Id},
+		subscription)
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.MatchedCount == 0 {
+		return errors.New("no webhook subscription could be updated")
+	}
+	return nil
+}
+
+//DeleteSubscription deletes the subscription together with its delivery log
+func (registry *mongodbWebhookRegistry) DeleteSubscription(subscriptionId uuid.UUID) error {
+	res, err := registry.subscriptionCollection.DeleteOne(context.Background(), bson.M{"id": subscriptionId})
+	if err != nil {
+		log.Print(err)
+		return err
+	}
+	if res.DeletedCount == 0 {
+		return errors.New("no webhook subscription could be deleted")
+	}
+
+	if _, err = registry.deliveryCollection.DeleteMany(context.Background(), bson.M{"subscriptionid": subscriptionId}); err != nil {
+		log.Print(err)
+	}
+	return nil
+}
+
+func (registry *mongodbWebhookRegistry) SaveDelivery(delivery *WebhookDelivery) error {
+	_, err := registry.deliveryCollection.InsertOne(context.Background(), delivery)
+	if err != nil {
+		log.Print(err)
+	}
+	return err
+}
+
+//GetDeliveries returns the latest deliveries of the subscription, newest first
+func (registry *mongodbWebhookRegistry) GetDeliveries(subscriptionId uuid.UUID, limit int64) ([]*WebhookDelivery, error) {
+	cursor, err := registry.deliveryCollection.Find(
+		context.Background(),
+		bson.M{"subscriptionid": subscriptionId},
+		options.Find().SetSort(bson.M{"createdat": -1}).SetLimit(limit))
+	if err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	var readData []*WebhookDelivery = make([]*WebhookDelivery, 0)
+	if err = cursor.All(context.Background(), &readData); err != nil {
+		log.Print(err)
+		return nil, err
+	}
+
+	return readData, nil
+}
+
+func (registry *mongodbWebhookRegistry) Close() error {
+	return registry.client.Disconnect(context.Background())
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const clockLayout = "15:04"
+
+//NoDataSettings fires an alert when a registered sensor has not delivered data for the given number of minutes
+type NoDataSettings struct {
+	Minutes  int               //0 uses the time after which the sensor is considered offline
+	Schedule []ReportingWindow //empty if the sensor reports all the time
+	Webhooks []string          //urls notified when the alert fires or resolves
+	Disabled bool
+}
+
+//ReportingWindow is a daily period in the timezone of the sensor in which the sensor is expected to deliver data
+//a window with From after To spans midnight
+type ReportingWindow struct {
+	Weekdays []time.Weekday //days the window starts on, empty for every day
+	From     string         //e.g. 08:00
+	To       string         //e.g. 18:00
+}
+
+//Validate checks the settings
+func (settings *NoDataSettings) Validate() error {
+	if settings.Minutes < 0 {
+		return fmt.Errorf("minutes %v is negative", settings.Minutes)
+	}
+	for _, window := range settings.Schedule {
+		if err := window.Validate(); err != nil {
+			return err
+		}
+	}
+	return ValidateWebhooks(settings.Webhooks)
+}
+
+//Validate checks the times and weekdays of the window
+func (window *ReportingWindow) Validate() error {
+	from, errFrom := time.Parse(clockLayout, window.From)
+	to, errTo := time.Parse(clockLayout, window.To)
+	if errFrom != nil || errTo != nil {
+		return fmt.Errorf("invalid reporting window %v-%v", window.From, window.To)
+	}
+	if from.Equal(to) {
+		return errors.New("reporting window must not be empty")
+	}
+	for _, weekday := range window.Weekdays {
+		if weekday < time.Sunday || weekday > time.Saturday {
+			return fmt.Errorf("invalid weekday %v", int(weekday))
+		}
+	}
+	return nil
+}
+
+//ActiveSince returns the start of the reporting window containing the time, the second result is false outside of all windows
+//without a schedule the sensor is always expected to report and the zero time is returned
+func (settings *NoDataSettings) ActiveSince(now time.Time, location *time.Location) (time.Time, bool) {
+	if len(settings.Schedule) == 0 {
+		return time.Time{}, true
+	}
+
+	now = now.In(location)
+	var since time.Time
+	active := false
+	for _, window := range settings.Schedule {
+		if start, ok := window.activeSince(now); ok && (!active || start.Before(since)) {
+			since = start
+			active = true
+		}
+	}
+	return since, active
+}
+
+func (window *ReportingWindow) activeSince(now time.Time) (time.Time, bool) {
+	from, errFrom := time.Parse(clockLayout, window.From)
+	to, errTo := time.Parse(clockLayout, window.To)
+	if errFrom != nil || errTo != nil {
+		return time.Time{}, false
+	}
+
+	//a window spanning midnight may have started the day before
+	for _, dayOffset := range []int{0, -1} {
+		day := now.AddDate(0, 0, dayOffset)
+		start := time.Date(day.Year(), day.Month(), day.Day(), from.Hour(), from.Minute(), 0, 0, now.Location())
+		end := time.Date(day.Year(), day.Month(), day.Day(), to.Hour(), to.Minute(), 0, 0, now.Location())
+		if !end.After(start) {
+			end = end.AddDate(0, 0, 1)
+		}
+		if window.includesWeekday(start.Weekday()) && !now.Before(start) && now.Before(end) {
+			return start, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (window *ReportingWindow) includesWeekday(weekday time.Weekday) bool {
+	if len(window.Weekdays) == 0 {
+		return true
+	}
+	for _, w := range window.Weekdays {
+		if w == weekday {
+			return true
+		}
+	}
+	return false
+}
+
+//NoDataTimeout is the time without data after which the no-data alert of the sensor fires
+func (sensor *WeatherSensor) NoDataTimeout() time.Duration {
+	if sensor.NoDataAlert == nil || sensor.NoDataAlert.Minutes == 0 {
+		return offlineFactor * sensor.ReportingInterval()
+	}
+	return time.Duration(sensor.NoDataAlert.Minutes) * time.Minute
+}
+
+//NewNoDataAlert creates a firing no-data alert of the sensor
+func NewNoDataAlert(sensor *WeatherSensor, lastSeen time.Time, firedAt time.Time) *Alert {
+	alert := new(Alert)
+	alert.Id = uuid.New()
+	alert.Kind = NoDataAlert
+	alert.SensorId = sensor.Id
+	alert.State = Firing
+	alert.FiredAt = firedAt
+	alert.Message = fmt.Sprintf("%v has not delivered data since %v", sensor.Name, lastSeen.Format(time.RFC3339))
+	if sensor.NoDataAlert != nil {
+		alert.Webhooks = sensor.NoDataAlert.Webhooks
+	}
+	return alert
+}
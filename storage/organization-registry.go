@@ -0,0 +1,116 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/google/uuid"
+)
+
+//OrganizationRegistry is the interface for different implementations of the registry of organizations
+type OrganizationRegistry interface {
+	CreateOrganization(organization *Organization) (*Organization, error)
+	GetOrganization(uuid.UUID) (*Organization, error)
+	GetOrganizations() ([]*Organization, error)
+	GetOrganizationsOfUser(userId string) ([]*Organization, error)
+	UpdateOrganization(*Organization) error
+	DeleteOrganization(uuid.UUID) error
+	Close() error
+}
+
+//OrganizationRole is the role of a member within an organization
+type OrganizationRole string
+
+const (
+	OrganizationAdmin  OrganizationRole = "admin"  //manages the members, sensors and stations
+	OrganizationMember OrganizationRole = "member" //manages the sensors, stations and their weather data
+	OrganizationViewer OrganizationRole = "viewer" //reads the sensors, stations and their weather data
+)
+
+//CanManage checks if the role allows changing sensors, stations and weather data of the organization
+func (role OrganizationRole) CanManage() bool {
+	return role == OrganizationAdmin || role == OrganizationMember
+}
+
+//Validate checks if the role is known
+func (role OrganizationRole) Validate() error {
+	switch role {
+	case OrganizationAdmin, OrganizationMember, OrganizationViewer:
+		return nil
+	}
+	return fmt.Errorf("unknown organization role %v", role)
+}
+
+//OrganizationMembership assigns a role to a user
+type OrganizationMembership struct {
+	UserId string
+	Role   OrganizationRole
+}
+
+//Organization is a tenant jointly owning sensors and stations, e.g. a club or a school
+type Organization struct {
+	Name        string
+	Id          uuid.UUID
+	Description string
+	Members     []OrganizationMembership
+}
+
+//Validate checks the settings of the organization, at least one admin is needed to manage the members
+func (organization *Organization) Validate() error {
+	if len(organization.Name) == 0 {
+		return errors.New("organization name is missing")
+	}
+	admins := 0
+	userIds := make(map[string]bool)
+	for _, member := range organization.Members {
+		if len(member.UserId) == 0 {
+			return errors.New("member user id is missing")
+		}
+		if userIds[member.UserId] {
+			return fmt.Errorf("user %v is a member more than once", member.UserId)
+		}
+		userIds[member.UserId] = true
+		if err := member.Role.Validate(); err != nil {
+			return err
+		}
+		if member.Role == OrganizationAdmin {
+			admins++
+		}
+	}
+	if admins == 0 {
+		return errors.New("organization needs at least one admin")
+	}
+	return nil
+}
+
+//RoleOf returns the role of the user, the second result is false if the user is no member
+func (organization *Organization) RoleOf(userId string) (OrganizationRole, bool) {
+	for _, member := range organization.Members {
+		if member.UserId == userId {
+			return member.Role, true
+		}
+	}
+	return "", false
+}
+
+//SetMember adds the user to the organization or changes the role of an existing member
+func (organization *Organization) SetMember(userId string, role OrganizationRole) {
+	for i, member := range organization.Members {
+		if member.UserId == userId {
+			organization.Members[i].Role = role
+			return
+		}
+	}
+	organization.Members = append(organization.Members, OrganizationMembership{UserId: userId, Role: role})
+}
+
+//RemoveMember removes the user from the organization
+func (organization *Organization) RemoveMember(userId string) {
+	members := make([]OrganizationMembership, 0)
+	for _, member := range organization.Members {
+		if member.UserId != userId {
+			members = append(members, member)
+		}
+	}
+	organization.Members = members
+}
@@ -0,0 +1,111 @@
+package storage
+
+import (
+	"log"
+	"sync"
+	"time"
+	"weather-data/config"
+)
+
+//rollupOverlap is the time before the latest aggregate that is aggregated again, for datapoints arriving late
+var rollupOverlap = 48 * time.Hour
+
+//RetentionJob periodically aggregates the stored weather data into the coarser resolutions and expires
+//each resolution of a sensor after the retention of its policy
+type RetentionJob struct {
+	weatherStorage RetentionStorage
+	sensorRegistry SensorRegistry
+	defaultPolicy  RetentionPolicy
+	interval       time.Duration
+	stop           chan struct{}
+	wg             sync.WaitGroup
+}
+
+//NewRetentionJob Factory, the job runs in the configured interval after Start
+func NewRetentionJob(weatherStorage RetentionStorage, sensorRegistry SensorRegistry, cfg config.RetentionConfig) *RetentionJob {
+	job := new(RetentionJob)
+	job.weatherStorage = weatherStorage
+	job.sensorRegistry = sensorRegistry
+	job.defaultPolicy = DefaultRetentionPolicy(cfg)
+	job.interval = cfg.Interval
+	job.stop = make(chan struct{})
+	return job
+}
+
+//Start runs the job immediately and then in the background until Close is called
+func (job *RetentionJob) Start() {
+	job.wg.Add(1)
+	go func() {
+		defer job.wg.Done()
+		job.Run(time.Now())
+		ticker := time.NewTicker(job.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				job.Run(now)
+			case <-job.stop:
+				return
+			}
+		}
+	}()
+}
+
+//Close stops the background job
+func (job *RetentionJob) Close() {
+	close(job.stop)
+	job.wg.Wait()
+}
+
+//Run aggregates the completed windows of each resolution and expires the weather data beyond the retention
+//the aggregation runs first, so datapoints are aggregated before they expire
+func (job *RetentionJob) Run(now time.Time) {
+	for _, resolution := range Resolutions {
+		window := resolution.Window()
+		if window == 0 {
+			continue
+		}
+
+		latest, err := job.weatherStorage.LatestRollup(resolution)
+		if err != nil {
+			log.Print(err)
+			return
+		}
+		start := time.Unix(0, 0)
+		if !latest.IsZero() {
+			start = latest.Add(-rollupOverlap).Truncate(window)
+		}
+		end := now.Truncate(window)
+		if !start.Before(end) {
+			continue
+		}
+		if err := job.weatherStorage.Rollup(resolution, start, end); err != nil {
+			log.Print(err)
+			return
+		}
+	}
+
+	sensorIds, err := job.weatherStorage.GetStoredSensorIds()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	for _, sensorId := range sensorIds {
+		//data of sensors which are not registered anymore expires with the default policy
+		sensor, err := job.sensorRegistry.GetSensor(sensorId)
+		if err != nil {
+			sensor = nil
+		}
+		policy := sensor.RetentionPolicy(job.defaultPolicy)
+
+		for _, resolution := range Resolutions {
+			retention := policy.Retention(resolution)
+			if retention == 0 {
+				continue
+			}
+			if err := job.weatherStorage.Expire(sensorId, resolution, now.Add(-retention)); err != nil {
+				log.Print(err)
+			}
+		}
+	}
+}
@@ -0,0 +1,196 @@
+package storage
+
+import (
+	"fmt"
+	"math"
+	"time"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+)
+
+//Resolution of stored weather data, the datapoints as received or their hourly and daily aggregates
+type Resolution string
+
+const (
+	NoAggregation     Resolution = "none"
+	HourlyAggregation Resolution = "1h"
+	DailyAggregation  Resolution = "1d"
+	AutoResolution    Resolution = "auto" //chosen by the range of the query with ChooseResolution
+)
+
+//Resolutions from fine to coarse, each aggregate is computed from the previous resolution
+var Resolutions = []Resolution{NoAggregation, HourlyAggregation, DailyAggregation}
+
+//aggregationWindows are the windows of the aggregated resolutions
+var aggregationWindows = map[Resolution]time.Duration{
+	HourlyAggregation: time.Hour,
+	DailyAggregation:  24 * time.Hour,
+}
+
+//rangeResolutions are the longest ranges queried in a resolution, longer ranges use the next coarser resolution
+var rangeResolutions = map[Resolution]time.Duration{
+	NoAggregation:     7 * 24 * time.Hour,
+	HourlyAggregation: 92 * 24 * time.Hour,
+}
+
+//cumulativeValueTypes are aggregated by their maximum, all other value types by their mean
+var cumulativeValueTypes = []SensorValueType{Rain}
+
+//directionValueTypes are angles in degrees, aggregated by the mean of their unit vectors
+var directionValueTypes = []SensorValueType{WindDirection}
+
+//ParseResolution parses a resolution, an empty value is AutoResolution
+func ParseResolution(value string) (Resolution, error) {
+	if len(value) == 0 {
+		return AutoResolution, nil
+	}
+	resolution := Resolution(value)
+	if resolution == AutoResolution {
+		return resolution, nil
+	}
+	for _, known := range Resolutions {
+		if resolution == known {
+			return resolution, nil
+		}
+	}
+	return "", fmt.Errorf("unknown resolution %v", value)
+}
+
+//Window returns the aggregation window of the resolution, 0 for datapoints
+func (resolution Resolution) Window() time.Duration {
+	return aggregationWindows[resolution]
+}
+
+//RetentionPolicy is the number of days the weather data is kept in each resolution, 0 keeps it forever
+type RetentionPolicy struct {
+	DataPointDays int
+	HourlyDays    int
+	DailyDays     int
+}
+
+//DefaultRetentionPolicy returns the configured policy of sensors without an own policy
+func DefaultRetentionPolicy(cfg config.RetentionConfig) RetentionPolicy {
+	return RetentionPolicy{DataPointDays: cfg.DataPointDays, HourlyDays: cfg.HourlyDays, DailyDays: cfg.DailyDays}
+}
+
+//Validate checks that coarser resolutions are kept at least as long as finer ones, so a range is always covered
+func (policy *RetentionPolicy) Validate() error {
+	//0 keeps a resolution forever
+	kept := func(resolution Resolution) int {
+		if days := policy.days(resolution); days != 0 {
+			return days
+		}
+		return math.MaxInt32
+	}
+	for i, resolution := range Resolutions {
+		if policy.days(resolution) < 0 {
+			return fmt.Errorf("retention of resolution %v is negative", resolution)
+		}
+		if i > 0 && kept(resolution) < kept(Resolutions[i-1]) {
+			return fmt.Errorf("resolution %v has to be kept at least as long as %v", resolution, Resolutions[i-1])
+		}
+	}
+	return nil
+}
+
+//Retention returns the time the resolution is kept, 0 if it is kept forever
+func (policy RetentionPolicy) Retention(resolution Resolution) time.Duration {
+	return time.Duration(policy.days(resolution)) * 24 * time.Hour
+}
+
+func (policy RetentionPolicy) days(resolution Resolution) int {
+	switch resolution {
+	case NoAggregation:
+		return policy.DataPointDays
+	case HourlyAggregation:
+		return policy.HourlyDays
+	case DailyAggregation:
+		return policy.DailyDays
+	}
+	return 0
+}
+
+//Stricter returns the shorter retention of each resolution, e.g. for the combined sensors of a station
+func (policy RetentionPolicy) Stricter(other RetentionPolicy) RetentionPolicy {
+	shorter := func(a, b int) int {
+		if a == 0 || (b != 0 && b < a) {
+			return b
+		}
+		return a
+	}
+	return RetentionPolicy{
+		DataPointDays: shorter(policy.DataPointDays, other.DataPointDays),
+		HourlyDays:    shorter(policy.HourlyDays, other.HourlyDays),
+		DailyDays:     shorter(policy.DailyDays, other.DailyDays),
+	}
+}
+
+//RetentionPolicy returns the policy of the sensor or the default policy, sensor may be nil
+func (sensor *WeatherSensor) RetentionPolicy(defaultPolicy RetentionPolicy) RetentionPolicy {
+	if sensor == nil || sensor.Retention == nil {
+		return defaultPolicy
+	}
+	return *sensor.Retention
+}
+
+//ChooseResolution returns the resolution of a query range: datapoints for up to a week, hourly aggregates for up to three months
+//and daily aggregates beyond. A coarser resolution is used if the start of the range is no longer kept in the resolution.
+func ChooseResolution(start time.Time, end time.Time, now time.Time, policy RetentionPolicy) Resolution {
+	length := end.Sub(start)
+	for i, resolution := range Resolutions {
+		last := i == len(Resolutions)-1
+		if maxLength, limited := rangeResolutions[resolution]; limited && length > maxLength {
+			continue
+		}
+		if retention := policy.Retention(resolution); retention != 0 && start.Before(now.Add(-retention)) && !last {
+			continue
+		}
+		return resolution
+	}
+	return NoAggregation
+}
+
+//RefreshRollups aggregates the windows between start and end again after their datapoints were deleted or corrected
+//windows not aggregated by the retention job yet and windows whose source resolution already expired are left alone,
+//so a range reaching into the expired data only refreshes the windows after the expiry
+func RefreshRollups(weatherStorage RetentionStorage, start time.Time, end time.Time, now time.Time, policy RetentionPolicy) error {
+	for i, resolution := range Resolutions {
+		window := resolution.Window()
+		if window == 0 {
+			continue
+		}
+
+		latest, err := weatherStorage.LatestRollup(resolution)
+		if err != nil {
+			return err
+		}
+		windowStart := start.Truncate(window)
+		windowEnd := end.Add(window - 1).Truncate(window)
+		if last := latest.Add(window); windowEnd.After(last) {
+			windowEnd = last
+		}
+		if retention := policy.Retention(Resolutions[i-1]); retention != 0 && windowStart.Before(now.Add(-retention)) {
+			windowStart = now.Add(-retention).Add(window - 1).Truncate(window)
+		}
+		if latest.IsZero() || !windowStart.Before(windowEnd) {
+			continue
+		}
+		if err := weatherStorage.Rollup(resolution, windowStart, windowEnd); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//RetentionStorage is implemented by weather storages keeping aggregated resolutions
+type RetentionStorage interface {
+	//Rollup aggregates the next finer resolution into the windows of the resolution between start and end
+	Rollup(resolution Resolution, start time.Time, end time.Time) error
+	//LatestRollup returns the time of the latest aggregate of the resolution, zero if there is none
+	LatestRollup(resolution Resolution) (time.Time, error)
+	//GetStoredSensorIds returns the sensors with stored weather data
+	GetStoredSensorIds() ([]uuid.UUID, error)
+	//Expire deletes the weather data of the sensor in the resolution before the time
+	Expire(sensorId uuid.UUID, resolution Resolution, before time.Time) error
+}
@@ -0,0 +1,76 @@
+package storage
+
+import (
+	"errors"
+
+	"github.com/google/uuid"
+)
+
+//scopedSensorRegistry restricts the sensor queries of a SensorRegistry to the sensors readable by a tenant
+//sensors of other tenants are not found, changes are passed to the underlying registry
+type scopedSensorRegistry struct {
+	SensorRegistry
+	tenant *Tenant
+}
+
+//NewScopedSensorRegistry Factory
+func NewScopedSensorRegistry(sensorRegistry SensorRegistry, tenant *Tenant) *scopedSensorRegistry {
+	scoped := new(scopedSensorRegistry)
+	scoped.SensorRegistry = sensorRegistry
+	scoped.tenant = tenant
+	return scoped
+}
+
+func (scoped *scopedSensorRegistry) GetSensor(sensorId uuid.UUID) (*WeatherSensor, error) {
+	sensor, err := scoped.SensorRegistry.GetSensor(sensorId)
+	if err != nil {
+		return nil, err
+	}
+	if !scoped.tenant.CanRead(sensor) {
+		return nil, errors.New("sensor does not exist")
+	}
+	return sensor, nil
+}
+
+func (scoped *scopedSensorRegistry) GetSensors() ([]*WeatherSensor, error) {
+	return scoped.filter(scoped.SensorRegistry.GetSensors())
+}
+
+func (scoped *scopedSensorRegistry) GetSensorsOfUser(userId string) ([]*WeatherSensor, error) {
+	return scoped.filter(scoped.SensorRegistry.GetSensorsOfUser(userId))
+}
+
+func (scoped *scopedSensorRegistry) GetSensorsOfOrganization(organizationId uuid.UUID) ([]*WeatherSensor, error) {
+	return scoped.filter(scoped.SensorRegistry.GetSensorsOfOrganization(organizationId))
+}
+
+func (scoped *scopedSensorRegistry) GetSensorsNear(latitude float64, longitude float64, radius float64) ([]*WeatherSensor, error) {
+	return scoped.filter(scoped.SensorRegistry.GetSensorsNear(latitude, longitude, radius))
+}
+
+func (scoped *scopedSensorRegistry) GetSensorsInBox(box GeoBox) ([]*WeatherSensor, error) {
+	return scoped.filter(scoped.SensorRegistry.GetSensorsInBox(box))
+}
+
+func (scoped *scopedSensorRegistry) GetSensorByExternalId(protocol IngestProtocol, id string) (*WeatherSensor, error) {
+	sensor, err := scoped.SensorRegistry.GetSensorByExternalId(protocol, id)
+	if err != nil {
+		return nil, err
+	}
+	if !scoped.tenant.CanRead(sensor) {
+		return nil, errors.New("sensor does not exist")
+	}
+	return sensor, nil
+}
+
+//Close does not close the underlying registry, as it is shared by all scopes
+func (scoped *scopedSensorRegistry) Close() error {
+	return nil
+}
+
+func (scoped *scopedSensorRegistry) filter(sensors []*WeatherSensor, err error) ([]*WeatherSensor, error) {
+	if err != nil {
+		return nil, err
+	}
+	return scoped.tenant.readable(sensors), nil
+}
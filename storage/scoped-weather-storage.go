@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//scopedWeatherStorage restricts a WeatherStorage to the sensors of a tenant
+//queries for sensors the tenant can not read return no data and an unrestricted query is limited to the readable sensors
+//only sensors owned by the tenant can be changed
+type scopedWeatherStorage struct {
+	weatherStorage WeatherStorage
+	sensorRegistry SensorRegistry
+	tenant         *Tenant
+	access         map[uuid.UUID]sensorAccess
+	mutex          sync.Mutex
+}
+
+//sensorAccess is the access of the tenant to a sensor, resolved once per scope
+type sensorAccess struct {
+	read  bool
+	write bool
+}
+
+//NewScopedWeatherStorage Factory, the access of the tenant is resolved with the sensor registry
+func NewScopedWeatherStorage(weatherStorage WeatherStorage, sensorRegistry SensorRegistry, tenant *Tenant) *scopedWeatherStorage {
+	scoped := new(scopedWeatherStorage)
+	scoped.weatherStorage = weatherStorage
+	scoped.sensorRegistry = sensorRegistry
+	scoped.tenant = tenant
+	scoped.access = make(map[uuid.UUID]sensorAccess)
+	return scoped
+}
+
+//accessOf returns the access of the tenant to the sensor, unregistered sensors are accessible to authenticated tenants if allowed
+func (scoped *scopedWeatherStorage) accessOf(sensorId uuid.UUID) sensorAccess {
+	scoped.mutex.Lock()
+	defer scoped.mutex.Unlock()
+
+	if access, exists := scoped.access[sensorId]; exists {
+		return access
+	}
+	var access sensorAccess
+	if sensor, err := scoped.sensorRegistry.GetSensor(sensorId); err == nil {
+		access = sensorAccess{read: scoped.tenant.CanRead(sensor), write: scoped.tenant.Owns(sensor)}
+	} else if scoped.tenant.AllowUnregistered && !scoped.tenant.IsAnonymous() && scoped.isUnregistered(sensorId) {
+		access = sensorAccess{read: true, write: true}
+	}
+	scoped.access[sensorId] = access
+	return access
+}
+
+//isUnregistered checks that the sensor is neither registered nor deleted
+func (scoped *scopedWeatherStorage) isUnregistered(sensorId uuid.UUID) bool {
+	if exists, err := scoped.sensorRegistry.ExistSensor(sensorId); err != nil || exists {
+		return false
+	}
+	_, err := scoped.sensorRegistry.GetDeletedSensor(sensorId)
+	return err != nil
+}
+
+func (scoped *scopedWeatherStorage) Save(data *WeatherData) error {
+	if !scoped.accessOf(data.SensorId).write {
+		return fmt.Errorf("sensor %v is not accessible", data.SensorId)
+	}
+	return scoped.weatherStorage.Save(data)
+}
+
+func (scoped *scopedWeatherStorage) GetData(query *WeatherQuery) ([]*WeatherData, error) {
+	scopedQuery, ok, err := scoped.scopeQuery(query)
+	if err != nil || !ok {
+		return make([]*WeatherData, 0), err
+	}
+	return scoped.weatherStorage.GetData(scopedQuery)
+}
+
+func (scoped *scopedWeatherStorage) GetRawData(query *WeatherQuery) ([]*WeatherData, error) {
+	scopedQuery, ok, err := scoped.scopeQuery(query)
+	if err != nil || !ok {
+		return make([]*WeatherData, 0), err
+	}
+	return scoped.weatherStorage.GetRawData(scopedQuery)
+}
+
+func (scoped *scopedWeatherStorage) GetLatestData(sensorIds []uuid.UUID) ([]*WeatherData, error) {
+	sensorIds, err := scoped.filter(sensorIds)
+	if err != nil {
+		return nil, err
+	}
+	return scoped.weatherStorage.GetLatestData(sensorIds)
+}
+
+func (scoped *scopedWeatherStorage) Delete(sensorId uuid.UUID, start time.Time, end time.Time) error {
+	if !scoped.accessOf(sensorId).write {
+		return fmt.Errorf("sensor %v is not accessible", sensorId)
+	}
+	return scoped.weatherStorage.Delete(sensorId, start, end)
+}
+
+func (scoped *scopedWeatherStorage) Correct(correction *WeatherData, removed []SensorValueType) (*WeatherData, error) {
+	if !scoped.accessOf(correction.SensorId).write {
+		return nil, fmt.Errorf("sensor %v is not accessible", correction.SensorId)
+	}
+	return scoped.weatherStorage.Correct(correction, removed)
+}
+
+//Close does not close the underlying storage, as it is shared by all scopes
+func (scoped *scopedWeatherStorage) Close() error {
+	return nil
+}
+
+//scopeQuery limits the sensors of the query to the scope, the second result is false if no sensor is left
+func (scoped *scopedWeatherStorage) scopeQuery(query *WeatherQuery) (*WeatherQuery, bool, error) {
+	scopedQuery := *query
+	sensorIds, err := scoped.filter(query.SensorIds)
+	if err != nil {
+		return nil, false, err
+	}
+	scopedQuery.SensorIds = sensorIds
+	return &scopedQuery, len(scopedQuery.SensorIds) != 0, nil
+}
+
+//filter returns the sensors readable by the tenant, all readable registered sensors if no sensor is given
+func (scoped *scopedWeatherStorage) filter(sensorIds []uuid.UUID) ([]uuid.UUID, error) {
+	result := make([]uuid.UUID, 0)
+	if len(sensorIds) == 0 {
+		sensors, err := scoped.sensorRegistry.GetSensors()
+		if err != nil {
+			return nil, err
+		}
+		for _, sensor := range scoped.tenant.readable(sensors) {
+			result = append(result, sensor.Id)
+		}
+		return result, nil
+	}
+	for _, sensorId := range sensorIds {
+		if scoped.accessOf(sensorId).read {
+			result = append(result, sensorId)
+		}
+	}
+	return result, nil
+}
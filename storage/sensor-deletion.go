@@ -0,0 +1,273 @@
+package storage
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+)
+
+//DeletionPolicy determines what happens to the weather data of a deleted sensor once it is purged
+type DeletionPolicy string
+
+const (
+	KeepData    DeletionPolicy = "keep"    //the weather data stays in the weather storage
+	ArchiveData DeletionPolicy = "archive" //the weather data is written to the archive directory and deleted
+	PurgeData   DeletionPolicy = "purge"   //the weather data is deleted
+)
+
+//ParseDeletionPolicy parses a deletion policy, an empty value is the default policy
+func ParseDeletionPolicy(value string, defaultPolicy DeletionPolicy) (DeletionPolicy, error) {
+	switch policy := DeletionPolicy(value); policy {
+	case "":
+		return defaultPolicy, nil
+	case KeepData, ArchiveData, PurgeData:
+		return policy, nil
+	}
+	return "", fmt.Errorf("unknown deletion policy %v", value)
+}
+
+//SensorDeletion marks a deleted sensor, it can be restored until it is purged
+type SensorDeletion struct {
+	Policy     DeletionPolicy
+	UserId     string
+	DeletedAt  time.Time
+	PurgeAt    time.Time
+	StationIds []uuid.UUID `json:",omitempty"` //stations the sensor was removed from, it is added again when restored
+}
+
+//NewSensorDeletion creates the deletion of a sensor by the user now, it is purged after the restore window
+func NewSensorDeletion(policy DeletionPolicy, userId string, restoreWindow time.Duration) *SensorDeletion {
+	deletion := new(SensorDeletion)
+	deletion.Policy = policy
+	deletion.UserId = userId
+	deletion.DeletedAt = time.Now()
+	deletion.PurgeAt = deletion.DeletedAt.Add(restoreWindow)
+	return deletion
+}
+
+//rawArchivePart is the archive part of the uncorrected values
+const rawArchivePart = "raw"
+
+//ArchiveStorage is implemented by weather storages able to export all weather data of a sensor
+type ArchiveStorage interface {
+	//ArchiveParts returns the parts the weather data of a sensor is stored in, together they contain everything Delete removes
+	//the empty part are the datapoints, the others are e.g. the uncorrected values and the aggregates
+	ArchiveParts() []string
+	//Archive writes the part of the weather data of the sensor as json lines, like the recordings of weather data
+	Archive(sensorId uuid.UUID, part string, w io.Writer) error
+}
+
+//SensorPurger carries out the deletion policy of deleted sensors after their restore window
+//the weather data is handled first, so a failed purge is repeated with the next run
+type SensorPurger struct {
+	sensorRegistry  SensorRegistry
+	weatherStorage  WeatherStorage
+	auditRegistry   AuditRegistry
+	alertRegistry   AlertRegistry
+	webhookRegistry WebhookRegistry
+	stationRegistry StationRegistry
+	archiveDir      string
+	interval        time.Duration
+	trigger         chan struct{}
+	stop            chan struct{}
+	wg              sync.WaitGroup
+}
+
+//NewSensorPurger Factory, the deleted sensors are purged in the configured interval after Start
+func NewSensorPurger(sensorRegistry SensorRegistry, weatherStorage WeatherStorage, auditRegistry AuditRegistry, alertRegistry AlertRegistry, webhookRegistry WebhookRegistry, stationRegistry StationRegistry, cfg config.DeletionConfig) *SensorPurger {
+	purger := new(SensorPurger)
+	purger.sensorRegistry = sensorRegistry
+	purger.weatherStorage = weatherStorage
+	purger.auditRegistry = auditRegistry
+	purger.alertRegistry = alertRegistry
+	purger.webhookRegistry = webhookRegistry
+	purger.stationRegistry = stationRegistry
+	purger.archiveDir = cfg.ArchiveDir
+	purger.interval = cfg.PurgeInterval
+	purger.trigger = make(chan struct{}, 1)
+	purger.stop = make(chan struct{})
+	return purger
+}
+
+//Start purges the due sensors immediately and then in the background until Close is called
+func (purger *SensorPurger) Start() {
+	purger.wg.Add(1)
+	go func() {
+		defer purger.wg.Done()
+		purger.Run(time.Now())
+		ticker := time.NewTicker(purger.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case now := <-ticker.C:
+				purger.Run(now)
+			case <-purger.trigger:
+				purger.Run(time.Now())
+			case <-purger.stop:
+				return
+			}
+		}
+	}()
+}
+
+//Trigger runs the background purge without waiting for the interval, e.g. for sensors deleted without restore window
+func (purger *SensorPurger) Trigger() {
+	select {
+	case purger.trigger <- struct{}{}:
+	default:
+	}
+}
+
+//Close stops the background purge
+func (purger *SensorPurger) Close() {
+	close(purger.stop)
+	purger.wg.Wait()
+}
+
+//Run purges the deleted sensors whose restore window ended
+func (purger *SensorPurger) Run(now time.Time) {
+	sensors, err := purger.sensorRegistry.GetDeletedSensors()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	for _, sensor := range sensors {
+		if sensor.Deletion.PurgeAt.After(now) {
+			continue
+		}
+		if err := purger.Purge(sensor); err != nil {
+			log.Printf("purge of sensor %v failed: %v", sensor.Id, err)
+		}
+	}
+}
+
+//Purge carries out the deletion policy of the deleted sensor and removes it from the registry
+func (purger *SensorPurger) Purge(sensor *WeatherSensor) error {
+	var action AuditAction
+	switch sensor.Deletion.Policy {
+	case ArchiveData:
+		if err := purger.archive(sensor); err != nil {
+			return err
+		}
+		action = DataArchived
+	case PurgeData:
+		action = DataPurged
+	}
+
+	if len(action) != 0 {
+		//datapoints with timestamps in the future are removed as well
+		if err := purger.weatherStorage.Delete(sensor.Id, time.Unix(0, 0), EndOfTime); err != nil {
+			return err
+		}
+		entry := NewAuditEntry(sensor.Id, sensor.Deletion.UserId, action, time.Unix(0, 0), EndOfTime, "sensor deleted")
+		if _, err := purger.auditRegistry.AddEntry(entry); err != nil {
+			log.Print(err)
+		}
+	}
+
+	if err := purger.removeReferences(sensor.Id); err != nil {
+		return err
+	}
+
+	log.Printf("purged sensor %v with policy %v", sensor.Id, sensor.Deletion.Policy)
+	return purger.sensorRegistry.PurgeSensor(sensor.Id)
+}
+
+//removeReferences deletes the alert rules of the sensor, resolves their open alerts and removes the sensor from webhook subscriptions and stations
+//subscriptions without sensors are deleted
+func (purger *SensorPurger) removeReferences(sensorId uuid.UUID) error {
+	rules, err := purger.alertRegistry.GetRulesOfSensor(sensorId)
+	if err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		if err := purger.alertRegistry.DeleteRule(rule.Id); err != nil {
+			return err
+		}
+	}
+	openAlerts, err := purger.alertRegistry.GetOpenAlerts([]uuid.UUID{sensorId})
+	if err != nil {
+		return err
+	}
+	for _, alert := range openAlerts {
+		alert.Resolve(alert.Value, time.Now())
+		if err := purger.alertRegistry.SaveAlert(alert); err != nil {
+			return err
+		}
+	}
+
+	subscriptions, err := purger.webhookRegistry.GetSubscriptionsOfSensor(sensorId)
+	if err != nil {
+		return err
+	}
+	for _, subscription := range subscriptions {
+		subscription.RemoveSensor(sensorId)
+		if len(subscription.SensorIds) == 0 {
+			err = purger.webhookRegistry.DeleteSubscription(subscription.Id)
+		} else {
+			err = purger.webhookRegistry.UpdateSubscription(subscription)
+		}
+		if err != nil {
+			return err
+		}
+	}
+
+	stations, err := purger.stationRegistry.GetStationsOfSensor(sensorId)
+	if err != nil {
+		return err
+	}
+	for _, station := range stations {
+		station.RemoveSensor(sensorId)
+		if err := purger.stationRegistry.UpdateStation(station); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+//archive writes the sensor to {id}.sensor.json, its datapoints to {id}.jsonl and the other parts of its weather data to {id}.{part}.jsonl in the archive directory
+//the datapoints can be replayed with a replay source keeping the timestamps
+func (purger *SensorPurger) archive(sensor *WeatherSensor) error {
+	archiveStorage, ok := purger.weatherStorage.(ArchiveStorage)
+	if !ok {
+		return fmt.Errorf("the weather storage does not support archiving")
+	}
+	if err := os.MkdirAll(purger.archiveDir, 0755); err != nil {
+		return err
+	}
+
+	metadata, err := json.MarshalIndent(sensor, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(purger.archiveDir, sensor.Id.String()+".sensor.json"), metadata, 0644); err != nil {
+		return err
+	}
+
+	for _, part := range archiveStorage.ArchiveParts() {
+		name := sensor.Id.String() + ".jsonl"
+		if len(part) != 0 {
+			name = sensor.Id.String() + "." + part + ".jsonl"
+		}
+		file, err := os.Create(filepath.Join(purger.archiveDir, name))
+		if err != nil {
+			return err
+		}
+		if err := archiveStorage.Archive(sensor.Id, part, file); err != nil {
+			file.Close()
+			return err
+		}
+		if err := file.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,6 +1,12 @@
 package storage
 
-import "github.com/google/uuid"
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
 
 type SensorRegistry interface {
 	RegisterSensor(sensor *WeatherSensor) (*WeatherSensor, error)
@@ -9,17 +15,151 @@ type SensorRegistry interface {
 	GetSensor(uuid.UUID) (*WeatherSensor, error)
 	GetSensors() ([]*WeatherSensor, error)
 	GetSensorsOfUser(userId string) ([]*WeatherSensor, error)
+	GetSensorsOfOrganization(organizationId uuid.UUID) ([]*WeatherSensor, error)
+	GetSensorsNear(latitude float64, longitude float64, radius float64) ([]*WeatherSensor, error)
+	GetSensorsInBox(box GeoBox) ([]*WeatherSensor, error)
+	GetSensorByExternalId(protocol IngestProtocol, id string) (*WeatherSensor, error)
 	UpdateSensor(*WeatherSensor) error
 	DeleteSensor(uuid.UUID) error
+	//SoftDeleteSensor moves the sensor with its Deletion to the deleted sensors, which are not found by the other queries
+	SoftDeleteSensor(sensor *WeatherSensor) error
+	GetDeletedSensor(uuid.UUID) (*WeatherSensor, error)
+	GetDeletedSensors() ([]*WeatherSensor, error)
+	//RestoreSensor moves the deleted sensor back to the registered sensors
+	RestoreSensor(uuid.UUID) (*WeatherSensor, error)
+	//PurgeSensor removes the deleted sensor finally
+	PurgeSensor(uuid.UUID) error
 	Close() error
 }
 
+//SensorVisibility controls who is allowed to read a sensor and its weather data
+type SensorVisibility string
+
+const (
+	Private SensorVisibility = "private"
+	Shared  SensorVisibility = "shared"
+	Public  SensorVisibility = "public"
+)
+
 //WeatherSensor is the data for a new Sensorregistration
 type WeatherSensor struct {
-	Name      string
-	Id        uuid.UUID
-	UserId    string
-	Location  string
-	Longitude float64
-	Latitude  float64
+	Name                      string
+	Id                        uuid.UUID
+	UserId                    string     //owning user, empty if the sensor is owned by an organization
+	OrganizationId            *uuid.UUID //owning organization, nil if the sensor is owned by a user
+	Location                  string
+	Longitude                 float64
+	Latitude                  float64
+	Elevation                 *float64 //meters above sea level
+	MountingHeight            *float64 //meters above ground
+	Indoor                    bool
+	HardwareModel             string
+	FirmwareVersion           string
+	Timezone                  string //IANA time zone, e.g. Europe/Berlin
+	Tags                      []string
+	Description               string
+	Calibrations              []Calibration
+	ExpectedReportingInterval int //seconds between two datapoints, 0 uses SENSOR_REPORTING_INTERVAL
+	NoDataAlert               *NoDataSettings
+	Visibility                SensorVisibility //empty is treated as private
+	SharedWith                []string         //user ids with read access to a shared sensor
+	ExternalIds               []ExternalId     //ids of the sensor within ingest protocols
+	AllowPlainCoap            bool             //accepts unauthenticated coap data over plain udp, sensors with a coap psk only accept dtls
+	CreatedAt                 time.Time        //set by the registry when the sensor is registered
+	Retention                 *RetentionPolicy `json:",omitempty"` //nil uses the default retention
+	Deletion                  *SensorDeletion  `json:",omitempty"` //set while a deleted sensor can be restored
+	Position                  *GeoPoint        `json:"-"`          //maintained by the registry for geospatial queries
+}
+
+//Validate checks the settings of the sensor
+func (sensor *WeatherSensor) Validate() error {
+	if len(sensor.Name) == 0 {
+		return errors.New("sensor name is missing")
+	}
+	if sensor.Latitude < -90 || sensor.Latitude > 90 {
+		return fmt.Errorf("latitude %v is out of range", sensor.Latitude)
+	}
+	if sensor.Longitude < -180 || sensor.Longitude > 180 {
+		return fmt.Errorf("longitude %v is out of range", sensor.Longitude)
+	}
+	if sensor.Elevation != nil && (*sensor.Elevation < -500 || *sensor.Elevation > 9000) {
+		return fmt.Errorf("elevation %v is out of range", *sensor.Elevation)
+	}
+	if sensor.MountingHeight != nil && (*sensor.MountingHeight < 0 || *sensor.MountingHeight > 1000) {
+		return fmt.Errorf("mounting height %v is out of range", *sensor.MountingHeight)
+	}
+	if _, err := LoadTimezone(sensor.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %v", sensor.Timezone)
+	}
+	for _, tag := range sensor.Tags {
+		if len(tag) == 0 {
+			return errors.New("tags must not be empty")
+		}
+	}
+	switch sensor.Visibility {
+	case "", Private, Shared, Public:
+	default:
+		return fmt.Errorf("unknown visibility %v", sensor.Visibility)
+	}
+	if sensor.ExpectedReportingInterval < 0 {
+		return fmt.Errorf("expected reporting interval %v is negative", sensor.ExpectedReportingInterval)
+	}
+	if sensor.NoDataAlert != nil {
+		if err := sensor.NoDataAlert.Validate(); err != nil {
+			return err
+		}
+	}
+	for _, calibration := range sensor.Calibrations {
+		if err := calibration.Validate(); err != nil {
+			return err
+		}
+	}
+	if sensor.Retention != nil {
+		if err := sensor.Retention.Validate(); err != nil {
+			return err
+		}
+	}
+	protocols := make(map[IngestProtocol]bool)
+	for _, externalId := range sensor.ExternalIds {
+		if err := externalId.Validate(); err != nil {
+			return err
+		}
+		if protocols[externalId.Protocol] {
+			return fmt.Errorf("more than one external id for protocol %v", externalId.Protocol)
+		}
+		protocols[externalId.Protocol] = true
+	}
+	if sensor.AllowPlainCoap && protocols[CoapProtocol] {
+		return errors.New("sensors with a coap psk can not accept plain coap data")
+	}
+	return nil
+}
+
+//LoadTimezone loads an IANA time zone, empty is UTC
+//Local is rejected, it would depend on the time zone of the server
+func LoadTimezone(name string) (*time.Location, error) {
+	if name == "Local" {
+		return nil, errors.New("the timezone Local is not supported")
+	}
+	return time.LoadLocation(name)
+}
+
+//TimeLocation returns the location of the sensors timezone, UTC if not set
+func (sensor *WeatherSensor) TimeLocation() *time.Location {
+	if sensor != nil {
+		if location, err := LoadTimezone(sensor.Timezone); err == nil {
+			return location
+		}
+	}
+	return time.UTC
+}
+
+//IsSharedWith checks if the sensor is shared with the user
+func (sensor *WeatherSensor) IsSharedWith(userId string) bool {
+	for _, sharedUserId := range sensor.SharedWith {
+		if sharedUserId == userId {
+			return true
+		}
+	}
+	return false
 }
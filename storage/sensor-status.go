@@ -0,0 +1,139 @@
+package storage
+
+import (
+	"time"
+	"weather-data/config"
+
+	"github.com/google/uuid"
+)
+
+//SensorStatusRegistry tracks the liveness of sensors from the accepted weather data
+type SensorStatusRegistry interface {
+	RecordData(data *WeatherData) error
+	RecordLinkQuality(sensorId uuid.UUID, link *LinkQuality) error
+	GetStatus(sensorId uuid.UUID) (*SensorStatus, error)
+	GetStatuses(sensorIds []uuid.UUID) ([]*SensorStatus, error)
+	Close() error
+}
+
+//SensorState is derived from the time since the last data and the expected reporting interval of the sensor
+type SensorState string
+
+const (
+	Online  SensorState = "online"  //data within twice the reporting interval
+	Stale   SensorState = "stale"   //data missing for some reporting intervals
+	Offline SensorState = "offline" //no data for more than ten reporting intervals
+	Unknown SensorState = "unknown" //no data since the tracking started
+)
+
+const (
+	staleFactor   = 2
+	offlineFactor = 10
+	//rateSmoothing weights the latest message interval for the smoothed message rate
+	rateSmoothing = 0.2
+)
+
+//SensorStatus is the liveness of a sensor
+type SensorStatus struct {
+	SensorId      uuid.UUID
+	FirstSeen     time.Time
+	LastSeen      time.Time //time the latest data was accepted
+	LastTimeStamp time.Time //timestamp of the latest datapoint
+	LastValues    map[SensorValueType]float64
+	MessageCount  int64
+	MessageRate   float64      //messages per minute, smoothed over the latest messages
+	Link          *LinkQuality `json:",omitempty"` //radio link of the latest uplink, only for sensors sending via radio networks
+	State         SensorState  `bson:"-"`          //derived when the status is read
+}
+
+//LinkQuality is the health of the radio link of a sensor, reported by the network server with each uplink
+type LinkQuality struct {
+	Rssi            float64 //dBm of the gateway with the best reception
+	Snr             float64 //dB of the gateway with the best reception
+	GatewayId       string  //gateway with the best reception
+	Gateways        int     //number of gateways which received the uplink
+	SpreadingFactor int
+	FrameCounter    int64
+	ReceivedAt      time.Time
+}
+
+//addGateway adds the reception of a gateway, the gateway with the highest rssi is kept
+func (link *LinkQuality) addGateway(gatewayId string, rssi float64, snr float64) {
+	if link.Gateways == 0 || rssi > link.Rssi {
+		link.GatewayId, link.Rssi, link.Snr = gatewayId, rssi, snr
+	}
+	link.Gateways++
+}
+
+//NewSensorStatus creates the status of a sensor without data
+func NewSensorStatus(sensorId uuid.UUID) *SensorStatus {
+	status := new(SensorStatus)
+	status.SensorId = sensorId
+	status.LastValues = make(map[SensorValueType]float64)
+	status.State = Unknown
+	return status
+}
+
+//copy copies the status, so it can be read while the original is updated
+func (status *SensorStatus) copy() *SensorStatus {
+	copied := *status
+	copied.LastValues = make(map[SensorValueType]float64, len(status.LastValues))
+	for k, v := range status.LastValues {
+		copied.LastValues[k] = v
+	}
+	if status.Link != nil {
+		link := *status.Link
+		copied.Link = &link
+	}
+	return &copied
+}
+
+//Record updates the status with data accepted at the given time
+func (status *SensorStatus) Record(data *WeatherData, now time.Time) {
+	if status.MessageCount == 0 {
+		status.FirstSeen = now
+	} else if interval := now.Sub(status.LastSeen).Minutes(); interval > 0 {
+		rate := 1 / interval
+		if status.MessageCount == 1 {
+			status.MessageRate = rate
+		} else {
+			status.MessageRate = rateSmoothing*rate + (1-rateSmoothing)*status.MessageRate
+		}
+	}
+	status.LastSeen = now
+	status.MessageCount++
+
+	//backfilled data does not replace newer values
+	if !data.TimeStamp.Before(status.LastTimeStamp) {
+		status.LastTimeStamp = data.TimeStamp
+		if status.LastValues == nil {
+			status.LastValues = make(map[SensorValueType]float64)
+		}
+		for k, v := range data.Values {
+			status.LastValues[k] = v
+		}
+	}
+}
+
+//StateAt derives the state of the sensor at the given time
+func (status *SensorStatus) StateAt(now time.Time, interval time.Duration) SensorState {
+	if status == nil || status.MessageCount == 0 {
+		return Unknown
+	}
+	age := now.Sub(status.LastSeen)
+	switch {
+	case age <= staleFactor*interval:
+		return Online
+	case age <= offlineFactor*interval:
+		return Stale
+	}
+	return Offline
+}
+
+//ReportingInterval is the expected interval between two datapoints of the sensor
+func (sensor *WeatherSensor) ReportingInterval() time.Duration {
+	if sensor == nil || sensor.ExpectedReportingInterval <= 0 {
+		return config.SensorReportingInterval
+	}
+	return time.Duration(sensor.ExpectedReportingInterval) * time.Second
+}
@@ -0,0 +1,120 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//StationRegistry is the interface for different implementations of the registry of weather stations
+type StationRegistry interface {
+	RegisterStation(station *Station) (*Station, error)
+	GetStation(uuid.UUID) (*Station, error)
+	GetStationsOfUser(userId string) ([]*Station, error)
+	GetStationsOfOrganization(organizationId uuid.UUID) ([]*Station, error)
+	GetStationsOfSensor(sensorId uuid.UUID) ([]*Station, error)
+	UpdateStation(*Station) error
+	DeleteStation(uuid.UUID) error
+	Close() error
+}
+
+//Station groups several sensors to one logical weather station
+type Station struct {
+	Name           string
+	Id             uuid.UUID
+	UserId         string     //owning user, empty if the station is owned by an organization
+	OrganizationId *uuid.UUID //owning organization, nil if the station is owned by a user
+	Description    string
+	Timezone       string //IANA time zone, e.g. Europe/Berlin
+	SensorIds      []uuid.UUID
+	Visibility     SensorVisibility              //empty is treated as private
+	SharedWith     []string                      //user ids with read access to a shared station
+	ValueSources   map[SensorValueType]uuid.UUID `json:",omitempty"` //member providing a value type, without an entry the first member in SensorIds reporting it
+}
+
+//Validate checks the settings of the station
+func (station *Station) Validate() error {
+	if len(station.Name) == 0 {
+		return errors.New("station name is missing")
+	}
+	if _, err := LoadTimezone(station.Timezone); err != nil {
+		return fmt.Errorf("unknown timezone %v", station.Timezone)
+	}
+	switch station.Visibility {
+	case "", Private, Shared, Public:
+	default:
+		return fmt.Errorf("unknown visibility %v", station.Visibility)
+	}
+	for valueType, sensorId := range station.ValueSources {
+		if !station.HasSensor(sensorId) {
+			return fmt.Errorf("source %v of %v is not a member of the station", sensorId, valueType)
+		}
+	}
+	return nil
+}
+
+//HasSensor checks if the sensor is a member of the station
+func (station *Station) HasSensor(sensorId uuid.UUID) bool {
+	for _, id := range station.SensorIds {
+		if id == sensorId {
+			return true
+		}
+	}
+	return false
+}
+
+//AddSensor adds the sensor to the members of the station
+func (station *Station) AddSensor(sensorId uuid.UUID) {
+	if !station.HasSensor(sensorId) {
+		station.SensorIds = append(station.SensorIds, sensorId)
+	}
+}
+
+//RemoveSensor removes the sensor from the members of the station
+func (station *Station) RemoveSensor(sensorId uuid.UUID) {
+	sensorIds := make([]uuid.UUID, 0)
+	for _, id := range station.SensorIds {
+		if id != sensorId {
+			sensorIds = append(sensorIds, id)
+		}
+	}
+	station.SensorIds = sensorIds
+	for valueType, id := range station.ValueSources {
+		if id == sensorId {
+			delete(station.ValueSources, valueType)
+		}
+	}
+}
+
+//sourceRank returns the priority of the member as source of the value type, lower is preferred, false if its values are ignored
+func (station *Station) sourceRank(sensorId uuid.UUID, valueType SensorValueType) (int, bool) {
+	if source, exists := station.ValueSources[valueType]; exists {
+		return 0, source == sensorId
+	}
+	for i, id := range station.SensorIds {
+		if id == sensorId {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+//IsSharedWith checks if the station is shared with the user
+func (station *Station) IsSharedWith(userId string) bool {
+	for _, sharedUserId := range station.SharedWith {
+		if sharedUserId == userId {
+			return true
+		}
+	}
+	return false
+}
+
+//TimeLocation returns the location of the stations timezone, UTC if not set
+func (station *Station) TimeLocation() *time.Location {
+	if location, err := LoadTimezone(station.Timezone); err == nil {
+		return location
+	}
+	return time.UTC
+}
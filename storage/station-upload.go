@@ -0,0 +1,81 @@
+package storage
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//uploadDateFormat is the format of the dateutc field, the value now stands for the time of the upload
+var uploadDateFormat = "2006-01-02 15:04:05"
+
+//uploadMissingValue is sent by some stations for values they don't measure
+var uploadMissingValue = -9999.0
+
+//uploadField maps a field of the weather underground and ecowitt upload protocols to a value type, values are converted from the unit if given
+type uploadField struct {
+	name      string
+	valueType SensorValueType
+	unit      Unit
+}
+
+//uploadFields are the known outdoor fields of the upload protocols, indoor values and fields of additional channels are ignored
+//fields of the same value type are ordered by priority, the first one present in an upload is used
+var uploadFields = []uploadField{
+	{"tempf", Temperature, Fahrenheit},
+	{"humidity", Humidity, ""},
+	{"baromrelin", SeaLevelPressure, InchOfMercury},
+	{"baromin", SeaLevelPressure, InchOfMercury},
+	{"baromabsin", Pressure, InchOfMercury},
+	{"absbaromin", Pressure, InchOfMercury},
+	{"dewptf", DewPoint, Fahrenheit},
+	{"windchillf", WindChill, Fahrenheit},
+	{"windspeedmph", WindSpeed, MilesPerHour},
+	{"windgustmph", WindGust, MilesPerHour},
+	{"winddir", WindDirection, ""},
+	{"rainratein", RainRate, InchPerHour},
+	{"rainin", RainRate, InchPerHour},
+	{"dailyrainin", Rain, Inch},
+	{"solarradiation", SolarRadiation, ""},
+	{"uv", UvIndex, ""},
+	{"UV", UvIndex, ""},
+	{"pm25_ch1", Pm25, ""},
+	{"AqPM2.5", Pm25, ""},
+	{"AqPM10", Pm10, ""},
+}
+
+//FromStationUpload converts the fields of a weather underground or ecowitt upload to WeatherData of the sensor
+//values are converted to the units of the catalog, unknown fields and values which are no numbers are ignored
+func FromStationUpload(sensorId uuid.UUID, fields url.Values, definitions []*ValueTypeDefinition) (*WeatherData, error) {
+	data := NewWeatherData()
+	data.SensorId = sensorId
+	data.TimeStamp = time.Now()
+
+	if date := fields.Get("dateutc"); len(date) != 0 && date != "now" {
+		timeStamp, err := time.ParseInLocation(uploadDateFormat, date, time.UTC)
+		if err != nil {
+			return nil, fmt.Errorf("invalid dateutc %v", date)
+		}
+		data.TimeStamp = timeStamp
+	}
+
+	for _, field := range uploadFields {
+		if _, exists := data.Values[field.valueType]; exists {
+			continue
+		}
+		value, err := strconv.ParseFloat(fields.Get(field.name), 64)
+		if err != nil || value == uploadMissingValue {
+			continue
+		}
+		if len(field.unit) != 0 {
+			if value, err = ConvertToCatalogUnit(value, field.unit, field.valueType, definitions); err != nil {
+				continue
+			}
+		}
+		data.Values[field.valueType] = value
+	}
+	return data, nil
+}
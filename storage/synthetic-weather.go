@@ -0,0 +1,127 @@
+package storage
+
+import (
+	"hash/fnv"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//default location of synthetic weather without a sensor location, central europe
+const (
+	DefaultSyntheticLatitude  = 50.0
+	DefaultSyntheticLongitude = 10.0
+)
+
+//channels of the noise of the synthetic weather, each value type gets independent noise
+const (
+	temperatureWeatherChannel int64 = iota + 1
+	temperatureSampleChannel
+	pressureDriftChannel
+	pressureWeatherChannel
+	pressureSampleChannel
+	dewPointChannel
+	humiditySampleChannel
+	co2Channel
+	offsetChannel
+)
+
+//SyntheticWeather generates realistic weather data of a virtual sensor
+//the values only depend on the sensor and the time, so a series is the same whenever it is generated:
+//a seasonal and diurnal temperature cycle, slowly drifting pressure with low pressure reducing the daily amplitude,
+//humidity following the temperature around a dew point and small noise on every sample
+type SyntheticWeather struct {
+	SensorId  uuid.UUID
+	Latitude  float64 //determines the seasons and the annual mean temperature
+	Longitude float64 //determines the solar time of the diurnal cycle
+	seed      uint64
+}
+
+//NewSyntheticWeather creates the generator of the sensor at the location, seeded by the sensor id
+func NewSyntheticWeather(sensorId uuid.UUID, latitude float64, longitude float64) *SyntheticWeather {
+	hash := fnv.New64a()
+	hash.Write(sensorId[:])
+
+	weather := new(SyntheticWeather)
+	weather.SensorId = sensorId
+	weather.Latitude = latitude
+	weather.Longitude = longitude
+	weather.seed = hash.Sum64()
+	return weather
+}
+
+//At returns the weather data at the time
+func (weather *SyntheticWeather) At(timeStamp time.Time) *WeatherData {
+	utc := timeStamp.UTC()
+	solarHour := float64(utc.Hour()) + float64(utc.Minute())/60 + float64(utc.Second())/3600 + weather.Longitude/15
+	//the warmest day is around the 200th day of the year on the northern hemisphere
+	season := math.Cos(2 * math.Pi * (float64(utc.YearDay()) - 200) / 365.25)
+	if weather.Latitude < 0 {
+		season = -season
+	}
+	latitude := math.Abs(weather.Latitude)
+
+	//pressure drifts over days, low pressure brings clouds which damp the daily cycle and lower the dew point depression
+	pressureAnomaly := 12*weather.smoothNoise(pressureDriftChannel, utc, 36*time.Hour) + 4*weather.smoothNoise(pressureWeatherChannel, utc, 6*time.Hour)
+	pressure := 1013 + 3*weather.random(offsetChannel, 0) + pressureAnomaly +
+		0.5*math.Cos(4*math.Pi*(solarHour-10)/24) + 0.1*weather.random(pressureSampleChannel, utc.Unix())
+	cloudiness := math.Max(0, math.Min(1, 0.5-pressureAnomaly/20))
+
+	meanTemperature := 27 - 0.35*latitude + 2*weather.random(offsetChannel, 1) + 0.2*latitude*season +
+		3*weather.smoothNoise(temperatureWeatherChannel, utc, 12*time.Hour)
+	amplitude := (5 + weather.random(offsetChannel, 2)) * (1 - 0.6*cloudiness)
+	temperature := meanTemperature + amplitude*math.Cos(2*math.Pi*(solarHour-15)/24) +
+		0.15*weather.random(temperatureSampleChannel, utc.Unix())
+
+	dewPointDepression := (5 + 2*weather.random(offsetChannel, 3)) * (1 - 0.7*cloudiness) * (1 + 0.3*weather.smoothNoise(dewPointChannel, utc, 8*time.Hour))
+	dewPoint := meanTemperature - amplitude*0.5 - dewPointDepression
+	humidity := 100 * math.Exp(17.62*dewPoint/(243.12+dewPoint)-17.62*temperature/(243.12+temperature))
+	humidity = math.Max(5, math.Min(100, humidity+weather.random(humiditySampleChannel, utc.Unix())))
+
+	//co2 accumulates at night without photosynthesis and mixing
+	co2Level := 420 + 25*math.Cos(2*math.Pi*(solarHour-4)/24) + 5*weather.random(co2Channel, utc.Unix())
+
+	data := NewWeatherData()
+	data.SensorId = weather.SensorId
+	data.TimeStamp = timeStamp
+	data.Values[Temperature] = round(temperature, 1)
+	data.Values[Humidity] = round(humidity, 1)
+	data.Values[Pressure] = round(pressure, 1)
+	data.Values[Co2Level] = round(co2Level, 0)
+	return data
+}
+
+//Series returns the weather data from start to end in the interval
+func (weather *SyntheticWeather) Series(start time.Time, end time.Time, interval time.Duration) []*WeatherData {
+	series := make([]*WeatherData, 0)
+	for timeStamp := start; !timeStamp.After(end); timeStamp = timeStamp.Add(interval) {
+		series = append(series, weather.At(timeStamp))
+	}
+	return series
+}
+
+//random returns a uniform value in [-1, 1) of the channel and index, with the splitmix64 finalizer
+func (weather *SyntheticWeather) random(channel int64, index int64) float64 {
+	x := weather.seed ^ uint64(channel)*0x9e3779b97f4a7c15 ^ uint64(index)*0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 30)) * 0xbf58476d1ce4e5b9
+	x = (x ^ (x >> 27)) * 0x94d049bb133111eb
+	x = x ^ (x >> 31)
+	return float64(x>>11)/float64(1<<53)*2 - 1
+}
+
+//smoothNoise interpolates random values placed at multiples of the period
+func (weather *SyntheticWeather) smoothNoise(channel int64, timeStamp time.Time, period time.Duration) float64 {
+	position := float64(timeStamp.UnixNano()) / float64(period)
+	index := math.Floor(position)
+	fraction := position - index
+	fraction = fraction * fraction * (3 - 2*fraction)
+
+	from, to := weather.random(channel, int64(index)), weather.random(channel, int64(index)+1)
+	return from + (to-from)*fraction
+}
+
+func round(value float64, decimals int) float64 {
+	factor := math.Pow(10, float64(decimals))
+	return math.Round(value*factor) / factor
+}
@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"github.com/google/uuid"
+)
+
+//Tenant is the caller of a request together with its organizations, the scope of the sensor registry and the weather storage
+//anonymous callers have no user id and only read public sensors
+type Tenant struct {
+	UserId            string
+	OrganizationIds   []uuid.UUID
+	AllowUnregistered bool //authenticated callers may access the weather data of unregistered sensors
+}
+
+//NewTenant Factory, the organizations are the ones the user is a member of
+func NewTenant(userId string, organizations []*Organization, allowUnregistered bool) *Tenant {
+	tenant := new(Tenant)
+	tenant.UserId = userId
+	tenant.AllowUnregistered = allowUnregistered
+	for _, organization := range organizations {
+		tenant.OrganizationIds = append(tenant.OrganizationIds, organization.Id)
+	}
+	return tenant
+}
+
+//IsAnonymous checks if the caller is not authenticated
+func (tenant *Tenant) IsAnonymous() bool {
+	return len(tenant.UserId) == 0
+}
+
+//Owns checks if the sensor belongs to the user or to one of its organizations
+func (tenant *Tenant) Owns(sensor *WeatherSensor) bool {
+	if tenant.IsAnonymous() {
+		return false
+	}
+	if sensor.OrganizationId == nil {
+		return sensor.UserId == tenant.UserId
+	}
+	for _, organizationId := range tenant.OrganizationIds {
+		if organizationId == *sensor.OrganizationId {
+			return true
+		}
+	}
+	return false
+}
+
+//CanRead checks if the sensor is owned by the tenant, shared with the user or public
+func (tenant *Tenant) CanRead(sensor *WeatherSensor) bool {
+	switch {
+	case sensor.Visibility == Public:
+		return true
+	case tenant.IsAnonymous():
+		return false
+	case tenant.Owns(sensor):
+		return true
+	case sensor.Visibility == Shared:
+		return sensor.IsSharedWith(tenant.UserId)
+	}
+	return false
+}
+
+//readable returns the sensors the tenant can read
+func (tenant *Tenant) readable(sensors []*WeatherSensor) []*WeatherSensor {
+	result := make([]*WeatherSensor, 0)
+	for _, sensor := range sensors {
+		if tenant.CanRead(sensor) {
+			result = append(result, sensor)
+		}
+	}
+	return result
+}
@@ -0,0 +1,223 @@
+package storage
+
+import (
+	"fmt"
+	"strings"
+)
+
+//Unit is the symbol of a physical unit, e.g. °C
+type Unit string
+
+const (
+	Celsius    Unit = "°C"
+	Fahrenheit Unit = "°F"
+	Kelvin     Unit = "K"
+
+	Hectopascal         Unit = "hPa"
+	Kilopascal          Unit = "kPa"
+	InchOfMercury       Unit = "inHg"
+	MillimeterOfMercury Unit = "mmHg"
+
+	MeterPerSecond   Unit = "m/s"
+	KilometerPerHour Unit = "km/h"
+	MilesPerHour     Unit = "mph"
+	Knots            Unit = "kn"
+
+	Millimeter        Unit = "mm"
+	Inch              Unit = "in"
+	MillimeterPerHour Unit = "mm/h"
+	InchPerHour       Unit = "in/h"
+
+	Meter Unit = "m"
+	Feet  Unit = "ft"
+)
+
+//UnitSystem is a set of preferred units
+type UnitSystem string
+
+const (
+	Metric   UnitSystem = "metric"
+	Imperial UnitSystem = "imperial"
+)
+
+//unitConversion converts a unit to the base unit of its dimension: base = value * factor + offset
+type unitConversion struct {
+	dimension string
+	factor    float64
+	offset    float64
+}
+
+var unitConversions = map[Unit]unitConversion{
+	Celsius:    {"temperature", 1, 0},
+	Fahrenheit: {"temperature", 5.0 / 9.0, -32 * 5.0 / 9.0},
+	Kelvin:     {"temperature", 1, -273.15},
+
+	Hectopascal:         {"pressure", 1, 0},
+	Kilopascal:          {"pressure", 10, 0},
+	InchOfMercury:       {"pressure", 33.8638866667, 0},
+	MillimeterOfMercury: {"pressure", 1.33322387415, 0},
+
+	MeterPerSecond:   {"speed", 1, 0},
+	KilometerPerHour: {"speed", 1 / 3.6, 0},
+	MilesPerHour:     {"speed", 0.44704, 0},
+	Knots:            {"speed", 1852.0 / 3600.0, 0},
+
+	Millimeter: {"length", 0.001, 0},
+	Inch:       {"length", 0.0254, 0},
+	Meter:      {"length", 1, 0},
+	Feet:       {"length", 0.3048, 0},
+
+	MillimeterPerHour: {"rate", 1, 0},
+	InchPerHour:       {"rate", 25.4, 0},
+}
+
+//imperialUnits maps metric units to their imperial counterpart
+var imperialUnits = map[Unit]Unit{
+	Celsius:           Fahrenheit,
+	Kelvin:            Fahrenheit,
+	Hectopascal:       InchOfMercury,
+	Kilopascal:        InchOfMercury,
+	MeterPerSecond:    MilesPerHour,
+	KilometerPerHour:  MilesPerHour,
+	Millimeter:        Inch,
+	MillimeterPerHour: InchPerHour,
+	Meter:             Feet,
+}
+
+//unitAliases are the accepted spellings of the units, matched case-insensitive
+var unitAliases = map[string]Unit{
+	"°c": Celsius, "degc": Celsius, "celsius": Celsius,
+	"°f": Fahrenheit, "degf": Fahrenheit, "fahrenheit": Fahrenheit,
+	"k": Kelvin, "kelvin": Kelvin,
+	"hpa": Hectopascal, "mbar": Hectopascal,
+	"kpa":  Kilopascal,
+	"inhg": InchOfMercury,
+	"mmhg": MillimeterOfMercury,
+	"m/s":  MeterPerSecond, "ms": MeterPerSecond,
+	"km/h": KilometerPerHour, "kmh": KilometerPerHour,
+	"mph": MilesPerHour,
+	"kn":  Knots, "kt": Knots, "knots": Knots,
+	"mm":   Millimeter,
+	"in":   Inch,
+	"mm/h": MillimeterPerHour, "mmh": MillimeterPerHour,
+	"in/h": InchPerHour, "inh": InchPerHour,
+	"m":  Meter,
+	"ft": Feet,
+}
+
+//ParseUnit parses a unit symbol or alias. The single letters C and F are only accepted in upper case
+//to not collide with the boolean query parameters f and t
+func ParseUnit(value string) (Unit, error) {
+	switch value {
+	case "C":
+		return Celsius, nil
+	case "F":
+		return Fahrenheit, nil
+	}
+	if unit, exists := unitAliases[strings.ToLower(value)]; exists {
+		return unit, nil
+	}
+	return "", fmt.Errorf("unknown unit %v", value)
+}
+
+//ParseUnitSystem parses the name of a UnitSystem
+func ParseUnitSystem(value string) (UnitSystem, error) {
+	switch UnitSystem(strings.ToLower(value)) {
+	case Metric:
+		return Metric, nil
+	case Imperial:
+		return Imperial, nil
+	}
+	return "", fmt.Errorf("unknown unit system %v", value)
+}
+
+//UnitIn returns the unit of the UnitSystem for a value measured in unit
+func (system UnitSystem) UnitIn(unit Unit) Unit {
+	if system == Imperial {
+		if imperial, exists := imperialUnits[unit]; exists {
+			return imperial
+		}
+	}
+	return unit
+}
+
+//ConvertUnit converts the value from one unit to another unit of the same dimension
+func ConvertUnit(value float64, from Unit, to Unit) (float64, error) {
+	if from == to {
+		return value, nil
+	}
+	fromConversion, fromExists := unitConversions[from]
+	toConversion, toExists := unitConversions[to]
+	if !fromExists || !toExists || fromConversion.dimension != toConversion.dimension {
+		return 0, fmt.Errorf("can not convert %v to %v", from, to)
+	}
+	base := value*fromConversion.factor + fromConversion.offset
+	return (base - toConversion.offset) / toConversion.factor, nil
+}
+
+//ConvertToCatalogUnit converts the value to the unit of the value type in the catalog
+func ConvertToCatalogUnit(value float64, from Unit, valueType SensorValueType, definitions []*ValueTypeDefinition) (float64, error) {
+	for _, definition := range definitions {
+		if definition.Name != valueType {
+			continue
+		}
+		unit, err := ParseUnit(definition.Unit)
+		if err != nil {
+			return 0, err
+		}
+		return ConvertUnit(value, from, unit)
+	}
+	return 0, fmt.Errorf("value type %v does not exist", valueType)
+}
+
+//ConvertUnits converts the values of all WeatherData to the units requested by the query
+//returns the units of the converted values
+func ConvertUnits(dataPoints []*WeatherData, query *WeatherQuery, definitions []*ValueTypeDefinition) (map[SensorValueType]Unit, error) {
+	units := make(map[SensorValueType]Unit)
+
+	for valueType := range query.Units {
+		if !containsValueType(definitions, valueType) {
+			return nil, fmt.Errorf("unit of %v is unknown", valueType)
+		}
+	}
+
+	for _, definition := range definitions {
+		if !query.Values[definition.Name] {
+			continue
+		}
+
+		storedUnit := Unit(definition.Unit)
+		if parsed, err := ParseUnit(definition.Unit); err == nil {
+			storedUnit = parsed
+		}
+
+		targetUnit := query.UnitSystem.UnitIn(storedUnit)
+		if requested, exists := query.Units[definition.Name]; exists {
+			targetUnit = requested
+		}
+
+		if targetUnit != storedUnit {
+			if _, err := ConvertUnit(0, storedUnit, targetUnit); err != nil {
+				return nil, fmt.Errorf("%v: %v", definition.Name, err)
+			}
+			for _, data := range dataPoints {
+				if value, exists := data.Values[definition.Name]; exists {
+					data.Values[definition.Name], _ = ConvertUnit(value, storedUnit, targetUnit)
+				}
+			}
+		}
+
+		units[definition.Name] = targetUnit
+	}
+
+	return units, nil
+}
+
+func containsValueType(definitions []*ValueTypeDefinition, valueType SensorValueType) bool {
+	for _, definition := range definitions {
+		if definition.Name == valueType {
+			return true
+		}
+	}
+	return false
+}
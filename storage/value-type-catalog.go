@@ -0,0 +1,172 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"math"
+)
+
+//ValueTypeCatalog is the interface for different implementations of the catalog of known SensorValueTypes
+type ValueTypeCatalog interface {
+	AddValueType(*ValueTypeDefinition) (*ValueTypeDefinition, error)
+	GetValueType(SensorValueType) (*ValueTypeDefinition, error)
+	GetValueTypes() ([]*ValueTypeDefinition, error)
+	UpdateValueType(*ValueTypeDefinition) error
+	DeleteValueType(SensorValueType) error
+	Close() error
+}
+
+//ValueTypeDefinition describes a SensorValueType with its unit and valid range
+type ValueTypeDefinition struct {
+	Name        SensorValueType
+	Unit        string
+	Description string
+	Min         *float64
+	Max         *float64
+	Precision   int
+}
+
+//DefaultValueTypes returns the definitions a new catalog is initialized with
+func DefaultValueTypes() []*ValueTypeDefinition {
+	return []*ValueTypeDefinition{
+		newValueTypeDefinition(Temperature, "°C", "air temperature", -90, 60, 2),
+		newValueTypeDefinition(Pressure, "hPa", "air pressure", 800, 1100, 2),
+		newValueTypeDefinition(Humidity, "%", "relative humidity", 0, 100, 2),
+		newValueTypeDefinition(Co2Level, "ppm", "co2 concentration", 0, 10000, 0),
+		newValueTypeDefinition(WindSpeed, "m/s", "average wind speed", 0, 120, 1),
+		newValueTypeDefinition(WindGust, "m/s", "wind gust speed", 0, 150, 1),
+		newValueTypeDefinition(WindDirection, "°", "wind direction", 0, 360, 0),
+		newValueTypeDefinition(Rain, "mm", "accumulated rain", 0, 2000, 1),
+		newValueTypeDefinition(RainRate, "mm/h", "rain rate", 0, 500, 1),
+		newValueTypeDefinition(SolarRadiation, "W/m²", "solar radiation", 0, 2000, 0),
+		newValueTypeDefinition(UvIndex, "", "uv index", 0, 20, 1),
+		newValueTypeDefinition(Pm25, "µg/m³", "particulate matter <= 2.5µm", 0, 1000, 1),
+		newValueTypeDefinition(Pm10, "µg/m³", "particulate matter <= 10µm", 0, 1000, 1),
+		newValueTypeDefinition(DewPoint, "°C", "dew point, derived from temperature and humidity", -90, 60, 2),
+		newValueTypeDefinition(HeatIndex, "°C", "heat index, derived from temperature and humidity", -90, 100, 2),
+		newValueTypeDefinition(WindChill, "°C", "wind chill, derived from temperature and wind speed", -120, 60, 2),
+		newValueTypeDefinition(AbsoluteHumidity, "g/m³", "absolute humidity, derived from temperature and humidity", 0, 200, 2),
+		newValueTypeDefinition(Humidex, "°C", "humidex, derived from temperature and humidity", -90, 100, 2),
+		newValueTypeDefinition(SeaLevelPressure, "hPa", "sea level pressure, derived from pressure, temperature and sensor elevation", 800, 1100, 2),
+	}
+}
+
+func newValueTypeDefinition(name SensorValueType, unit, description string, min, max float64, precision int) *ValueTypeDefinition {
+	return &ValueTypeDefinition{
+		Name:        name,
+		Unit:        unit,
+		Description: description,
+		Min:         &min,
+		Max:         &max,
+		Precision:   precision,
+	}
+}
+
+//Validate checks the definition itself
+func (definition *ValueTypeDefinition) Validate() error {
+	if len(definition.Name) == 0 {
+		return errors.New("value type name is missing")
+	}
+	if definition.Name == SensorValueType(SensorId) || definition.Name == SensorValueType(TimeStamp) {
+		return fmt.Errorf("value type name %v is reserved", definition.Name)
+	}
+	if definition.Min != nil && definition.Max != nil && *definition.Min > *definition.Max {
+		return errors.New("min is greater than max")
+	}
+	if definition.Precision < 0 {
+		return errors.New("precision must not be negative")
+	}
+	return nil
+}
+
+//ValidateValue checks if the value is within the range of the definition
+func (definition *ValueTypeDefinition) ValidateValue(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("%v is not a valid number", definition.Name)
+	}
+	if definition.Min != nil && value < *definition.Min {
+		return fmt.Errorf("%v %v is lower than %v", definition.Name, value, *definition.Min)
+	}
+	if definition.Max != nil && value > *definition.Max {
+		return fmt.Errorf("%v %v is greater than %v", definition.Name, value, *definition.Max)
+	}
+	return nil
+}
+
+//Round rounds the value to the precision of the definition
+func (definition *ValueTypeDefinition) Round(value float64) float64 {
+	factor := math.Pow(10, float64(definition.Precision))
+	return math.Round(value*factor) / factor
+}
+
+//PrecisionIn returns the precision of the definition for values converted to the unit, so they keep the resolution of the stored values
+//e.g. pressure with one decimal in hPa has three decimals in inHg
+func (definition *ValueTypeDefinition) PrecisionIn(unit Unit) int {
+	storedUnit, err := ParseUnit(definition.Unit)
+	if err != nil || len(unit) == 0 || unit == storedUnit {
+		return definition.Precision
+	}
+	from, fromExists := unitConversions[storedUnit]
+	to, toExists := unitConversions[unit]
+	if !fromExists || !toExists || from.dimension != to.dimension {
+		return definition.Precision
+	}
+
+	precision := definition.Precision + int(math.Ceil(math.Log10(to.factor/from.factor)-1e-9))
+	if precision < 0 {
+		return 0
+	}
+	return precision
+}
+
+//ValueTypeNames returns the names of all definitions
+func ValueTypeNames(definitions []*ValueTypeDefinition) []SensorValueType {
+	names := make([]SensorValueType, 0, len(definitions))
+	for _, definition := range definitions {
+		names = append(names, definition.Name)
+	}
+	return names
+}
+
+//ValidateWeatherData checks all values of the WeatherData against the catalog definitions
+//values of types not contained in the catalog are not validated
+func ValidateWeatherData(data *WeatherData, definitions []*ValueTypeDefinition) error {
+	for _, definition := range definitions {
+		if value, exists := data.Values[definition.Name]; exists {
+			if err := definition.ValidateValue(value); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+//RemoveInvalidValues removes all values of the WeatherData not matching their catalog definitions
+//the raw value of a removed calibrated value is removed as well, so no raw value is stored without its value
+func RemoveInvalidValues(data *WeatherData, definitions []*ValueTypeDefinition) []error {
+	var errs []error
+	for _, definition := range definitions {
+		if value, exists := data.Values[definition.Name]; exists {
+			if err := definition.ValidateValue(value); err != nil {
+				delete(data.Values, definition.Name)
+				delete(data.RawValues, definition.Name)
+				errs = append(errs, err)
+			}
+		}
+	}
+	return errs
+}
+
+//RoundValues rounds all values of the WeatherData slice to the precision of their catalog definitions
+//values converted by ConvertUnits are rounded to the precision in their units
+func RoundValues(dataPoints []*WeatherData, definitions []*ValueTypeDefinition, units map[SensorValueType]Unit) []*WeatherData {
+	for _, definition := range definitions {
+		factor := math.Pow(10, float64(definition.PrecisionIn(units[definition.Name])))
+		for _, data := range dataPoints {
+			if value, exists := data.Values[definition.Name]; exists {
+				data.Values[definition.Name] = math.Round(value*factor) / factor
+			}
+		}
+	}
+	return dataPoints
+}
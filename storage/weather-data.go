@@ -2,7 +2,7 @@ package storage
 
 import (
 	"math"
-	"math/rand"
+	"sort"
 	"time"
 
 	"github.com/google/uuid"
@@ -15,6 +15,16 @@ const (
 	Pressure    SensorValueType = "pressure"
 	Humidity    SensorValueType = "humidity"
 	Co2Level    SensorValueType = "co2level"
+
+	WindSpeed      SensorValueType = "windspeed"
+	WindGust       SensorValueType = "windgust"
+	WindDirection  SensorValueType = "winddirection"
+	Rain           SensorValueType = "rain"
+	RainRate       SensorValueType = "rainrate"
+	SolarRadiation SensorValueType = "solarradiation"
+	UvIndex        SensorValueType = "uvindex"
+	Pm25           SensorValueType = "pm25"
+	Pm10           SensorValueType = "pm10"
 )
 
 const (
@@ -22,34 +32,24 @@ const (
 	TimeStamp string = "timeStamp"
 )
 
+//GetSensorValueTypes returns the SensorValueTypes of the default catalog
 func GetSensorValueTypes() []SensorValueType {
-	return []SensorValueType{Temperature, Pressure, Humidity, Co2Level}
+	return ValueTypeNames(DefaultValueTypes())
 }
 
 //WeatherData type
 type WeatherData struct {
 	Values    map[SensorValueType]float64
+	RawValues map[SensorValueType]float64 //uncorrected values of calibrated SensorValueTypes
 	SensorId  uuid.UUID
 	TimeStamp time.Time
 }
 
-//NewRandomWeatherData creates random WeatherData
-func NewRandomWeatherData() *WeatherData {
-	var data = new(WeatherData)
-	data.Values = make(map[SensorValueType]float64)
-	data.Values[Humidity] = rand.Float64() * 100
-	data.Values[Pressure] = rand.Float64()*80 + 960
-	data.Values[Temperature] = rand.Float64()*40 - 5
-	data.Values[Co2Level] = rand.Float64()*50 + 375
-	data.SensorId = uuid.New()
-	data.TimeStamp = time.Now()
-	return data
-}
-
 //NewRandomWeatherData creates random WeatherData
 func NewWeatherData() *WeatherData {
 	var data = new(WeatherData)
 	data.Values = make(map[SensorValueType]float64)
+	data.RawValues = make(map[SensorValueType]float64)
 	return data
 }
 
@@ -140,3 +140,54 @@ func ToMap(dataPoints []*WeatherData) []map[string]interface{} {
 	}
 	return result
 }
+
+//MergeWeatherData merges the WeatherData of the members of a station to one timeline with the id of the station
+//datapoints within the same interval are combined, each value type is taken from a single member chosen by the ValueSources of the station,
+//without a source from the first member in SensorIds reporting it; only values of the same member within the interval are averaged
+func MergeWeatherData(dataPoints []*WeatherData, station *Station, interval time.Duration) []*WeatherData {
+	var result = make([]*WeatherData, 0)
+	merged := make(map[time.Time]*WeatherData)
+	counts := make(map[time.Time]map[SensorValueType]int)
+	ranks := make(map[time.Time]map[SensorValueType]int)
+
+	for _, data := range dataPoints {
+		timestamp := data.TimeStamp
+		if interval > 0 {
+			timestamp = timestamp.Truncate(interval)
+		}
+
+		mergedData, exists := merged[timestamp]
+		if !exists {
+			mergedData = NewWeatherData()
+			mergedData.SensorId = station.Id
+			mergedData.TimeStamp = timestamp
+			merged[timestamp] = mergedData
+			counts[timestamp] = make(map[SensorValueType]int)
+			ranks[timestamp] = make(map[SensorValueType]int)
+			result = append(result, mergedData)
+		}
+
+		for k, v := range data.Values {
+			rank, isSource := station.sourceRank(data.SensorId, k)
+			if !isSource {
+				continue
+			}
+			count := counts[timestamp][k]
+			if count != 0 && rank > ranks[timestamp][k] {
+				continue
+			}
+			if count != 0 && rank < ranks[timestamp][k] {
+				count = 0
+			}
+			mergedData.Values[k] = (mergedData.Values[k]*float64(count) + v) / float64(count+1)
+			counts[timestamp][k] = count + 1
+			ranks[timestamp][k] = rank
+		}
+	}
+
+	sort.Slice(result, func(p, q int) bool {
+		return result[p].TimeStamp.Before(result[q].TimeStamp)
+	})
+
+	return result
+}
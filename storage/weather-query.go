@@ -15,47 +15,83 @@ type WeatherQuery struct {
 	SensorIds     []uuid.UUID
 	MaxDataPoints int
 	Values        map[SensorValueType]bool
+	UnitSystem    UnitSystem
+	Units         map[SensorValueType]Unit
+	Location      *time.Location
+	Resolution    Resolution    //empty queries the datapoints
+	Interval      time.Duration //datapoints of merged timelines are combined within the interval
 }
 
+//dateLayout is accepted for start, end and day, the day boundaries are determined in the location of the query
+var dateLayout = "2006-01-02"
+
 //NewWeatherQuery creates a new empty WeatherQuery
 func NewWeatherQuery() *WeatherQuery {
 	query := new(WeatherQuery)
 	query.MaxDataPoints = -1
 	query.Values = make(map[SensorValueType]bool)
+	query.UnitSystem = Metric
+	query.Units = make(map[SensorValueType]Unit)
+	query.Location = time.UTC
+	query.Interval = time.Minute
 	return query
 }
 
-func (query *WeatherQuery) Init() {
+//Init sets the default time range and queries all given SensorValueTypes
+func (query *WeatherQuery) Init(valueTypes []SensorValueType) {
 	query.Start = time.Now().Add(-1 * time.Hour * 24 * 14)
 	query.End = time.Now()
 	query.SensorIds = make([]uuid.UUID, 0)
-	for _, sensorValueType := range GetSensorValueTypes() {
+	for _, sensorValueType := range valueTypes {
 		query.Values[sensorValueType] = true
 	}
 }
 
-func ParseWeatherQuery(query url.Values) (*WeatherQuery, error) {
+//ParseWeatherQuery creates a WeatherQuery from url parameters, valueTypes are queried if not disabled explicitly
+//dates without time are interpreted as day boundaries in the given location, unless overwritten by the timezone parameter
+func ParseWeatherQuery(query url.Values, valueTypes []SensorValueType, location *time.Location) (*WeatherQuery, error) {
 	result := NewWeatherQuery()
-	result.Init()
+	result.Init(valueTypes)
+	result.Location = location
 
 	start := query.Get("start")
 	end := query.Get("end")
+	day := query.Get("day")
+	timezone := query.Get("timezone")
 	max := query.Get("maxDataPoints")
+	units := query.Get("units")
+	resolution := query.Get("resolution")
+	interval := query.Get("interval")
+
+	if len(timezone) != 0 {
+		if tval, err := LoadTimezone(timezone); err == nil {
+			result.Location = tval
+		} else if err != nil {
+			return nil, err
+		}
+	}
 
 	if len(start) != 0 {
-		if tval, err := time.Parse(time.RFC3339, start); err == nil {
+		if tval, err := parseQueryTime(start, result.Location, false); err == nil {
 			result.Start = tval
 		} else if err != nil {
-			fmt.Println(err)
 			return nil, err
 		}
 	}
 
 	if len(end) != 0 {
-		if tval, err := time.Parse(time.RFC3339, end); err == nil {
+		if tval, err := parseQueryTime(end, result.Location, true); err == nil {
 			result.End = tval
 		} else if err != nil {
-			fmt.Println(err)
+			return nil, err
+		}
+	}
+
+	if len(day) != 0 {
+		if tval, err := time.ParseInLocation(dateLayout, day, result.Location); err == nil {
+			result.Start = tval
+			result.End = tval.AddDate(0, 0, 1)
+		} else if err != nil {
 			return nil, err
 		}
 	}
@@ -64,19 +100,72 @@ func ParseWeatherQuery(query url.Values) (*WeatherQuery, error) {
 		if tval, err := strconv.Atoi(max); err == nil {
 			result.MaxDataPoints = tval
 		} else if err != nil {
-			fmt.Println(err)
 			return nil, err
 		}
 	}
 
+	if len(units) != 0 {
+		if system, err := ParseUnitSystem(units); err == nil {
+			result.UnitSystem = system
+		} else {
+			return nil, err
+		}
+	}
+
+	if len(interval) != 0 {
+		if tval, err := time.ParseDuration(interval); err == nil && tval > 0 {
+			result.Interval = tval
+		} else if err != nil {
+			return nil, err
+		} else {
+			return nil, fmt.Errorf("invalid interval %v", interval)
+		}
+	}
+
+	if tval, err := ParseResolution(resolution); err == nil {
+		result.Resolution = tval
+	} else {
+		return nil, err
+	}
+
+	known := make(map[SensorValueType]bool)
+	for _, valueType := range valueTypes {
+		known[valueType] = true
+	}
+	for _, derived := range derivedValues {
+		known[derived.Name] = true
+	}
+
+	//a value type parameter is either a bool to (de)select the value type or the unit the values should be converted to
 	for k, v := range query {
-		if k == "start" || k == "end" {
+		if k == "start" || k == "end" || k == "day" || k == "timezone" || k == "interval" || k == "maxDataPoints" || k == "units" || k == "resolution" {
 			continue
 		}
-		if bval, err := strconv.ParseBool(v[0]); err == nil {
+		if !known[SensorValueType(k)] {
+			return nil, fmt.Errorf("unknown value type %v", k)
+		}
+		if unit, err := ParseUnit(v[0]); err == nil {
+			result.Values[SensorValueType(k)] = true
+			result.Units[SensorValueType(k)] = unit
+		} else if bval, err := strconv.ParseBool(v[0]); err == nil {
 			result.Values[SensorValueType(k)] = bval
 		}
 	}
 
 	return result, nil
 }
+
+//parseQueryTime parses a RFC3339 time or a date, a date used as end includes the whole day
+func parseQueryTime(value string, location *time.Location, isEnd bool) (time.Time, error) {
+	if tval, err := time.Parse(time.RFC3339, value); err == nil {
+		return tval, nil
+	}
+	tval, err := time.ParseInLocation(dateLayout, value, location)
+	if err != nil {
+		return tval, err
+	}
+	if isEnd {
+		tval = tval.AddDate(0, 0, 1)
+	}
+	return tval, nil
+}
@@ -1,8 +1,29 @@
 package storage
 
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//ErrNoDataPoint is returned when a corrected datapoint does not exist
+var ErrNoDataPoint = errors.New("datapoint does not exist")
+
+//EndOfTime is the latest timestamp InfluxDB can store, used to reach datapoints with timestamps in the future
+var EndOfTime = time.Unix(0, math.MaxInt64).UTC()
+
 //WeatherStorage interface for different storage-implementations of weather data
 type WeatherStorage interface {
 	Save(*WeatherData) error
 	GetData(*WeatherQuery) ([]*WeatherData, error)
+	GetRawData(*WeatherQuery) ([]*WeatherData, error)
+	GetLatestData(sensorIds []uuid.UUID) ([]*WeatherData, error)
+	//Delete removes the weather data of the sensor from start up to end in all resolutions, including uncorrected values
+	Delete(sensorId uuid.UUID, start time.Time, end time.Time) error
+	//Correct replaces the values of the stored datapoint with the sensor and timestamp of the correction, other values are kept
+	//removed value types are deleted from the datapoint. returns the datapoint before the correction
+	Correct(correction *WeatherData, removed []SensorValueType) (*WeatherData, error)
 	Close() error
 }
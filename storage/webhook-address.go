@@ -0,0 +1,71 @@
+package storage
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"syscall"
+	"time"
+)
+
+//privateNetworks are the ranges of private, shared and unspecified addresses, loopback and link-local addresses are checked by net.IP
+var privateNetworks = parseNetworks("0.0.0.0/8", "10.0.0.0/8", "100.64.0.0/10", "172.16.0.0/12", "192.168.0.0/16", "fc00::/7")
+
+func parseNetworks(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+//IsPrivateAddress checks if the ip is a loopback, link-local or private address, webhooks must not reach internal hosts
+func IsPrivateAddress(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, network := range privateNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+//isPrivateHost checks if the host of a webhook url is localhost or a private ip, other host names are checked when connecting
+func isPrivateHost(host string) bool {
+	host = strings.TrimSuffix(strings.ToLower(host), ".")
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && IsPrivateAddress(ip)
+}
+
+//NewWebhookClient creates the http client of webhook deliveries
+//unless private networks are allowed, connections to private addresses are refused after the name is resolved, so a host name can not point to an internal host
+func NewWebhookClient(timeout time.Duration, allowPrivateNetworks bool) *http.Client {
+	if allowPrivateNetworks {
+		return &http.Client{Timeout: timeout}
+	}
+
+	dialer := &net.Dialer{
+		Timeout: timeout,
+		Control: func(network string, address string, c syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || IsPrivateAddress(ip) {
+				return fmt.Errorf("webhook address %v is not allowed", host)
+			}
+			return nil
+		},
+	}
+	return &http.Client{Timeout: timeout, Transport: &http.Transport{DialContext: dialer.DialContext}}
+}
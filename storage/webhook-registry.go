@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+//WebhookRegistry is the interface for different implementations of the storage of webhook subscriptions and their deliveries
+type WebhookRegistry interface {
+	AddSubscription(subscription *WebhookSubscription) (*WebhookSubscription, error)
+	GetSubscription(uuid.UUID) (*WebhookSubscription, error)
+	GetSubscriptionsOfUser(userId string) ([]*WebhookSubscription, error)
+	GetSubscriptionsOfSensor(sensorId uuid.UUID) ([]*WebhookSubscription, error)
+	UpdateSubscription(*WebhookSubscription) error
+	DeleteSubscription(uuid.UUID) error
+	SaveDelivery(delivery *WebhookDelivery) error
+	GetDeliveries(subscriptionId uuid.UUID, limit int64) ([]*WebhookDelivery, error)
+	Close() error
+}
+
+//WebhookSubscription pushes new weather data of the sensors to the url
+type WebhookSubscription struct {
+	Id         uuid.UUID
+	UserId     string
+	Url        string
+	Secret     string            //key of the HMAC-SHA256 signature of each delivery
+	SensorIds  []uuid.UUID       //sensors whose weather data is delivered
+	ValueTypes []SensorValueType //delivered value types, empty for all
+	Disabled   bool
+}
+
+//Validate checks the settings of the subscription
+func (subscription *WebhookSubscription) Validate() error {
+	if err := ValidateWebhooks([]string{subscription.Url}); err != nil {
+		return err
+	}
+	if len(subscription.SensorIds) == 0 {
+		return errors.New("at least one sensor is needed")
+	}
+	if len(subscription.Secret) == 0 {
+		return errors.New("secret is missing")
+	}
+	return nil
+}
+
+//RemoveSensor removes the sensor from the sensors of the subscription
+func (subscription *WebhookSubscription) RemoveSensor(sensorId uuid.UUID) {
+	sensorIds := make([]uuid.UUID, 0)
+	for _, id := range subscription.SensorIds {
+		if id != sensorId {
+			sensorIds = append(sensorIds, id)
+		}
+	}
+	subscription.SensorIds = sensorIds
+}
+
+//Filter returns the weather data reduced to the value types of the subscription, the second result is false if no value is left
+func (subscription *WebhookSubscription) Filter(data *WeatherData) (*WeatherData, bool) {
+	if len(subscription.ValueTypes) == 0 {
+		return data, len(data.Values) != 0
+	}
+
+	filtered := NewWeatherData()
+	filtered.SensorId = data.SensorId
+	filtered.TimeStamp = data.TimeStamp
+	for _, valueType := range subscription.ValueTypes {
+		if value, exists := data.Values[valueType]; exists {
+			filtered.Values[valueType] = value
+		}
+	}
+	return filtered, len(filtered.Values) != 0
+}
+
+//WebhookDeliveryState is the outcome of a delivery
+type WebhookDeliveryState string
+
+const (
+	Delivered WebhookDeliveryState = "delivered"
+	Failed    WebhookDeliveryState = "failed"
+)
+
+//WebhookDelivery is the log entry of a delivery to a subscription
+type WebhookDelivery struct {
+	Id             uuid.UUID
+	SubscriptionId uuid.UUID
+	SensorId       uuid.UUID
+	DataTimeStamp  time.Time
+	CreatedAt      time.Time
+	State          WebhookDeliveryState
+	Attempts       int
+	StatusCode     int
+	Error          string
+}
@@ -0,0 +1,226 @@
+package weathersource
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"log"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/fxamacker/cbor/v2"
+	"github.com/google/uuid"
+	piondtls "github.com/pion/dtls/v2"
+	"github.com/plgd-dev/go-coap/v2/dtls"
+	"github.com/plgd-dev/go-coap/v2/message"
+	"github.com/plgd-dev/go-coap/v2/message/codes"
+	"github.com/plgd-dev/go-coap/v2/mux"
+	coapNet "github.com/plgd-dev/go-coap/v2/net"
+	"github.com/plgd-dev/go-coap/v2/udp"
+	"github.com/plgd-dev/go-coap/v2/udp/client"
+)
+
+//pskIdentityKey is the context key of the psk identity of a dtls connection
+type pskIdentityKey struct{}
+
+//coapWeatherSource is a coap server accepting weather data as cbor or json map on /sensor/<id>
+//sensors with a coap external id only accept data via dtls, authenticated by the psk of the external id
+//plain udp data is only accepted for registered sensors which opted in with AllowPlainCoap
+type coapWeatherSource struct {
+	WeatherSourceBase
+	sourceFailure
+	sensorRegistry storage.SensorRegistry
+	udpServer      *udp.Server
+	dtlsServer     *dtls.Server
+	listeners      []io.Closer
+}
+
+//NewCoapSource Factory function for coapWeatherSource, starts the plain udp and the dtls endpoint if configured
+func NewCoapSource(cfg config.CoapConfig, sensorRegistry storage.SensorRegistry) (*coapWeatherSource, error) {
+	source := new(coapWeatherSource)
+	source.sensorRegistry = sensorRegistry
+
+	router := mux.NewRouter()
+	if err := router.Handle("/sensor/{id}", mux.HandlerFunc(source.handleWeatherData)); err != nil {
+		return nil, err
+	}
+
+	if len(cfg.Address) != 0 {
+		listener, err := coapNet.NewListenUDP("udp", cfg.Address)
+		if err != nil {
+			return nil, err
+		}
+		source.listeners = append(source.listeners, listener)
+		source.udpServer = udp.NewServer(udp.WithMux(router))
+		go source.serve(func() error { return source.udpServer.Serve(listener) })
+		log.Printf("coap server listening on %v", cfg.Address)
+	}
+
+	if len(cfg.DtlsAddress) != 0 {
+		listener, err := coapNet.NewDTLSListener("udp", cfg.DtlsAddress, &piondtls.Config{
+			PSK:             source.preSharedKey,
+			PSKIdentityHint: []byte("weather-data"),
+			CipherSuites: []piondtls.CipherSuiteID{
+				piondtls.TLS_PSK_WITH_AES_128_CCM_8,
+				piondtls.TLS_PSK_WITH_AES_128_CCM,
+				piondtls.TLS_PSK_WITH_AES_128_GCM_SHA256,
+				piondtls.TLS_PSK_WITH_AES_128_CBC_SHA256,
+			},
+		})
+		if err != nil {
+			source.Close()
+			return nil, err
+		}
+		source.listeners = append(source.listeners, listener)
+		source.dtlsServer = dtls.NewServer(dtls.WithMux(router), dtls.WithOnNewClientConn(func(cc *client.ClientConn, dtlsConn *piondtls.Conn) {
+			cc.SetContextValue(pskIdentityKey{}, string(dtlsConn.ConnectionState().IdentityHint))
+		}))
+		go source.serve(func() error { return source.dtlsServer.Serve(listener) })
+		log.Printf("coap dtls server listening on %v", cfg.DtlsAddress)
+	}
+
+	return source, nil
+}
+
+//Close stops the servers
+func (source *coapWeatherSource) Close() {
+	if source.udpServer != nil {
+		source.udpServer.Stop()
+	}
+	if source.dtlsServer != nil {
+		source.dtlsServer.Stop()
+	}
+	//the servers only close listeners they already serve
+	for _, listener := range source.listeners {
+		listener.Close()
+	}
+}
+
+func (source *coapWeatherSource) serve(serve func() error) {
+	if err := serve(); err != nil {
+		source.fail(err)
+	}
+}
+
+//preSharedKey returns the key of the sensor with the psk identity
+func (source *coapWeatherSource) preSharedKey(identity []byte) ([]byte, error) {
+	sensor, err := source.sensorRegistry.GetSensorByExternalId(storage.CoapProtocol, string(identity))
+	if err != nil {
+		return nil, fmt.Errorf("unknown psk identity %s", identity)
+	}
+	return []byte(sensor.ExternalId(storage.CoapProtocol).Password), nil
+}
+
+func (source *coapWeatherSource) handleWeatherData(w mux.ResponseWriter, r *mux.Message) {
+	if r.Code != codes.POST && r.Code != codes.PUT {
+		setCoapResponse(w, codes.MethodNotAllowed)
+		return
+	}
+
+	sensorId, err := uuid.Parse(r.RouteParams.Vars["id"])
+	if err != nil {
+		setCoapResponse(w, codes.BadRequest)
+		return
+	}
+
+	if code, ok := source.authorize(w, sensorId); !ok {
+		setCoapResponse(w, code)
+		return
+	}
+
+	weatherData, err := decodeCoapPayload(r)
+	if err != nil {
+		setCoapResponse(w, codes.BadRequest)
+		return
+	}
+	weatherData.SensorId = sensorId
+	if weatherData.TimeStamp.IsZero() {
+		weatherData.TimeStamp = time.Now()
+	}
+
+	source.NewWeatherData(weatherData)
+	setCoapResponse(w, codes.Changed)
+}
+
+//authorize checks that dtls connections only send data of the sensor of their psk identity
+//plain udp is unauthenticated, so only sensors which explicitly allow it accept its data
+func (source *coapWeatherSource) authorize(w mux.ResponseWriter, sensorId uuid.UUID) (codes.Code, bool) {
+	sensor, err := source.sensorRegistry.GetSensor(sensorId)
+	identity, isDtls := w.Client().Context().Value(pskIdentityKey{}).(string)
+
+	if !isDtls {
+		if err != nil || !sensor.AllowPlainCoap {
+			return codes.Unauthorized, false
+		}
+		return codes.Empty, true
+	}
+
+	if err != nil {
+		return codes.NotFound, false
+	}
+	if externalId := sensor.ExternalId(storage.CoapProtocol); externalId == nil || externalId.Id != identity {
+		return codes.Forbidden, false
+	}
+	return codes.Empty, true
+}
+
+//decodeCoapPayload decodes a cbor or json map of value types and values, the format is detected if no content format is given
+//the time stamp is optional, either RFC3339 or unix seconds
+func decodeCoapPayload(r *mux.Message) (*storage.WeatherData, error) {
+	if r.Body == nil {
+		return nil, errors.New("missing payload")
+	}
+	payload, err := ioutil.ReadAll(r.Body)
+	if err != nil || len(payload) == 0 {
+		return nil, errors.New("missing payload")
+	}
+
+	contentFormat, err := r.Options.ContentFormat()
+	if err != nil {
+		contentFormat = message.AppJSON
+		if payload[0] != '{' {
+			contentFormat = message.AppCBOR
+		}
+	}
+
+	values := make(map[string]interface{})
+	switch contentFormat {
+	case message.AppJSON:
+		decoder := json.NewDecoder(bytes.NewReader(payload))
+		err = decoder.Decode(&values)
+	case message.AppCBOR:
+		err = cbor.Unmarshal(payload, &values)
+	default:
+		return nil, fmt.Errorf("unsupported content format %v", contentFormat)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for key, value := range values {
+		switch number := value.(type) {
+		case uint64:
+			values[key] = float64(number)
+		case int64:
+			values[key] = float64(number)
+		case float32:
+			values[key] = float64(number)
+		}
+	}
+	if seconds, isNumber := values[storage.TimeStamp].(float64); isNumber {
+		values[storage.TimeStamp] = unixTimeStamp(seconds)
+	}
+	delete(values, storage.SensorId)
+
+	return storage.FromMap(values)
+}
+
+func setCoapResponse(w mux.ResponseWriter, code codes.Code) {
+	if err := w.SetResponse(code, message.TextPlain, nil); err != nil {
+		log.Print(err)
+	}
+}
@@ -0,0 +1,109 @@
+package weathersource
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//httpPollTimeout is the timeout of one request of the http source
+var httpPollTimeout = 30 * time.Second
+
+//httpWeatherSource polls a json document with the values of one sensor, like the body of POST /sensor/{id}/weather-data
+type httpWeatherSource struct {
+	WeatherSourceBase
+	sourceFailure
+	config   config.HttpSourceConfig
+	sensorId uuid.UUID
+	client   *http.Client
+	done     chan struct{}
+}
+
+//NewHttpSource Factory function for httpWeatherSource, the document is requested in the configured interval after Start
+func NewHttpSource(cfg config.HttpSourceConfig) (*httpWeatherSource, error) {
+	sensorId, err := uuid.Parse(cfg.SensorId)
+	if err != nil {
+		return nil, err
+	}
+
+	source := new(httpWeatherSource)
+	source.config = cfg
+	source.sensorId = sensorId
+	source.client = &http.Client{Timeout: httpPollTimeout}
+	source.done = make(chan struct{})
+	return source, nil
+}
+
+//Start begins polling
+func (source *httpWeatherSource) Start() {
+	go source.poll()
+	log.Printf("polling weather data of sensor %v from %v", source.sensorId, source.config.Url)
+}
+
+//Close stops polling
+func (source *httpWeatherSource) Close() {
+	close(source.done)
+}
+
+//poll requests the document until the source is closed, a failed request stops the source
+func (source *httpWeatherSource) poll() {
+	ticker := time.NewTicker(time.Duration(source.config.Interval) * time.Second)
+	defer ticker.Stop()
+
+	for {
+		if err := source.request(); err != nil {
+			source.fail(err)
+			return
+		}
+		select {
+		case <-source.done:
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (source *httpWeatherSource) request() error {
+	request, err := http.NewRequest("GET", source.config.Url, nil)
+	if err != nil {
+		return err
+	}
+	for key, value := range source.config.Headers {
+		request.Header.Set(key, value)
+	}
+
+	response, err := source.client.Do(request)
+	if err != nil {
+		return err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v returned %v", source.config.Url, response.Status)
+	}
+
+	data := make(map[string]interface{})
+	if err := json.NewDecoder(response.Body).Decode(&data); err != nil {
+		return err
+	}
+
+	data[storage.SensorId] = source.sensorId
+	if _, containsTimeStamp := data[storage.TimeStamp]; !containsTimeStamp {
+		data[storage.TimeStamp] = time.Now()
+	}
+
+	weatherData, err := storage.FromMap(data)
+	if err != nil {
+		return err
+	}
+	if len(weatherData.Values) != 0 {
+		source.NewWeatherData(weatherData)
+	}
+	return nil
+}
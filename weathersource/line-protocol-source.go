@@ -0,0 +1,72 @@
+package weathersource
+
+import (
+	"fmt"
+	"log"
+	"net"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+)
+
+//lineProtocolWeatherSource receives the influxdb line protocol on a udp socket, one or more lines per datagram
+type lineProtocolWeatherSource struct {
+	WeatherSourceBase
+	sourceFailure
+	config     config.LineProtocolConfig
+	precision  time.Duration
+	connection net.PacketConn
+	closed     chan struct{}
+}
+
+//NewLineProtocolSource Factory function for lineProtocolWeatherSource
+func NewLineProtocolSource(cfg config.LineProtocolConfig) (*lineProtocolWeatherSource, error) {
+	source := new(lineProtocolWeatherSource)
+	source.config = cfg
+	source.closed = make(chan struct{})
+
+	var exists bool
+	if source.precision, exists = storage.LinePrecisions[cfg.UdpPrecision]; !exists {
+		return nil, fmt.Errorf("unknown precision %v", cfg.UdpPrecision)
+	}
+
+	var err error
+	if source.connection, err = net.ListenPacket("udp", cfg.UdpAddress); err != nil {
+		return nil, err
+	}
+
+	go source.readDatagrams()
+
+	log.Printf("receiving line protocol on %v", cfg.UdpAddress)
+	return source, nil
+}
+
+//Close udp socket
+func (source *lineProtocolWeatherSource) Close() {
+	close(source.closed)
+	source.connection.Close()
+}
+
+func (source *lineProtocolWeatherSource) readDatagrams() {
+	buffer := make([]byte, 65536)
+	for {
+		n, address, err := source.connection.ReadFrom(buffer)
+		if err != nil {
+			select {
+			case <-source.closed:
+			default:
+				source.fail(err)
+			}
+			return
+		}
+
+		dataPoints, err := storage.ParseLineProtocol(buffer[:n], source.config.SensorTag, source.precision, time.Now())
+		if err != nil {
+			log.Printf("invalid line protocol from %v: %v", address, err)
+			continue
+		}
+		for _, weatherData := range dataPoints {
+			source.NewWeatherData(weatherData)
+		}
+	}
+}
@@ -0,0 +1,219 @@
+package weathersource
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+	"github.com/google/uuid"
+)
+
+var publishQos byte = 1
+
+//publishConnectRetryInterval is the delay between the attempts to connect to the mqtt-broker for republishing
+var publishConnectRetryInterval = 10 * time.Second
+
+//invalidDiscoveryIdCharacters are not allowed in the topic ids of home assistant discovery
+var invalidDiscoveryIdCharacters = regexp.MustCompile("[^a-zA-Z0-9_-]")
+
+//homeAssistantDeviceClasses maps value types to the device classes of home assistant, value types without a class are published as generic sensors
+var homeAssistantDeviceClasses = map[storage.SensorValueType]string{
+	storage.Temperature:      "temperature",
+	storage.DewPoint:         "temperature",
+	storage.HeatIndex:        "temperature",
+	storage.WindChill:        "temperature",
+	storage.Humidex:          "temperature",
+	storage.Humidity:         "humidity",
+	storage.Pressure:         "atmospheric_pressure",
+	storage.SeaLevelPressure: "atmospheric_pressure",
+	storage.Co2Level:         "carbon_dioxide",
+	storage.WindSpeed:        "wind_speed",
+	storage.WindGust:         "wind_speed",
+	storage.Rain:             "precipitation",
+	storage.RainRate:         "precipitation_intensity",
+	storage.SolarRadiation:   "irradiance",
+	storage.Pm25:             "pm25",
+	storage.Pm10:             "pm10",
+}
+
+//MqttPublisher republishes processed weather data as one json document per datapoint to the mqtt-broker
+type MqttPublisher struct {
+	config           config.MqttPublishConfig
+	mqttClient       mqtt.Client
+	sensorRegistry   storage.SensorRegistry
+	valueTypeCatalog storage.ValueTypeCatalog
+	announcements    map[uuid.UUID]*discoveryAnnouncement
+	mutex            sync.Mutex
+}
+
+//discoveryAnnouncement remembers the published discovery configs of a sensor
+type discoveryAnnouncement struct {
+	device     string
+	valueTypes map[storage.SensorValueType]bool
+}
+
+//homeAssistantDevice groups the entities of a sensor in home assistant
+type homeAssistantDevice struct {
+	Identifiers   []string `json:"identifiers"`
+	Name          string   `json:"name"`
+	Model         string   `json:"model,omitempty"`
+	SwVersion     string   `json:"sw_version,omitempty"`
+	SuggestedArea string   `json:"suggested_area,omitempty"`
+}
+
+//homeAssistantSensorConfig is the discovery config of one value type of a sensor
+type homeAssistantSensorConfig struct {
+	Name              string              `json:"name"`
+	UniqueId          string              `json:"unique_id"`
+	StateTopic        string              `json:"state_topic"`
+	ValueTemplate     string              `json:"value_template"`
+	UnitOfMeasurement string              `json:"unit_of_measurement,omitempty"`
+	DeviceClass       string              `json:"device_class,omitempty"`
+	StateClass        string              `json:"state_class"`
+	Device            homeAssistantDevice `json:"device"`
+}
+
+//NewMqttPublisher Factory function for MqttPublisher, connects with the settings of the mqtt source
+//the connection is retried in the background, weather data is not published while the broker is not reachable
+func NewMqttPublisher(mqttCfg config.MqttConfig, cfg config.MqttPublishConfig, sensorRegistry storage.SensorRegistry, valueTypeCatalog storage.ValueTypeCatalog) *MqttPublisher {
+	publisher := new(MqttPublisher)
+	publisher.config = cfg
+	publisher.sensorRegistry = sensorRegistry
+	publisher.valueTypeCatalog = valueTypeCatalog
+	publisher.announcements = make(map[uuid.UUID]*discoveryAnnouncement)
+
+	opts := newMqttClientOptions(mqttCfg)
+	opts.SetConnectRetry(true)
+	opts.SetConnectRetryInterval(publishConnectRetryInterval)
+	opts.SetOnConnectHandler(func(client mqtt.Client) {
+		log.Print("successfully connected to mqtt-broker for republishing")
+	})
+	publisher.mqttClient = mqtt.NewClient(opts)
+	publisher.mqttClient.Connect()
+	return publisher
+}
+
+//Close mqtt client
+func (publisher *MqttPublisher) Close() {
+	publisher.mqttClient.Disconnect(2)
+}
+
+//HandleWeatherData publishes the weather data on the state topic of the sensor, intended to be registered with OnNewWeatherData
+//only the data of public sensors is published, as everyone with access to the broker can read it
+func (publisher *MqttPublisher) HandleWeatherData(data *storage.WeatherData) {
+	if !publisher.mqttClient.IsConnectionOpen() {
+		return
+	}
+	sensor, err := publisher.sensorRegistry.GetSensor(data.SensorId)
+	if err != nil || sensor.Visibility != storage.Public {
+		return
+	}
+
+	if publisher.config.Discovery {
+		publisher.announce(sensor, data)
+	}
+
+	payload, err := json.Marshal(data.ToMap())
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	publisher.publish(publisher.StateTopic(data.SensorId), publisher.config.Retain, payload)
+}
+
+//StateTopic returns the topic the weather data of the sensor is published on
+func (publisher *MqttPublisher) StateTopic(sensorId uuid.UUID) string {
+	return strings.ReplaceAll(publisher.config.Topic, "{sensorId}", sensorId.String())
+}
+
+//announce publishes the discovery configs of value types not yet announced, all configs are published again if the metadata of the sensor changed
+func (publisher *MqttPublisher) announce(sensor *storage.WeatherSensor, data *storage.WeatherData) {
+	device := newHomeAssistantDevice(sensor)
+	deviceKey := fmt.Sprintf("%v", device)
+
+	publisher.mutex.Lock()
+	announcement, exists := publisher.announcements[sensor.Id]
+	if !exists || announcement.device != deviceKey {
+		announcement = &discoveryAnnouncement{device: deviceKey, valueTypes: make(map[storage.SensorValueType]bool)}
+		publisher.announcements[sensor.Id] = announcement
+	}
+	valueTypes := make([]storage.SensorValueType, 0)
+	for valueType := range data.Values {
+		if !announcement.valueTypes[valueType] {
+			announcement.valueTypes[valueType] = true
+			valueTypes = append(valueTypes, valueType)
+		}
+	}
+	publisher.mutex.Unlock()
+
+	sort.Slice(valueTypes, func(i, j int) bool { return valueTypes[i] < valueTypes[j] })
+	for _, valueType := range valueTypes {
+		sensorConfig := publisher.newHomeAssistantSensorConfig(sensor, valueType, device)
+		payload, err := json.Marshal(sensorConfig)
+		if err != nil {
+			log.Print(err)
+			continue
+		}
+		publisher.publish(publisher.discoveryTopic(sensor.Id, valueType), true, payload)
+	}
+}
+
+//discoveryTopic returns the topic of the discovery config, <prefix>/sensor/<sensorId>/<valueType>/config
+func (publisher *MqttPublisher) discoveryTopic(sensorId uuid.UUID, valueType storage.SensorValueType) string {
+	return fmt.Sprintf("%s/sensor/%s/%s/config", publisher.config.DiscoveryPrefix, sensorId, discoveryId(string(valueType)))
+}
+
+func (publisher *MqttPublisher) newHomeAssistantSensorConfig(sensor *storage.WeatherSensor, valueType storage.SensorValueType, device homeAssistantDevice) *homeAssistantSensorConfig {
+	sensorConfig := &homeAssistantSensorConfig{
+		Name:          string(valueType),
+		UniqueId:      discoveryId(sensor.Id.String() + "_" + string(valueType)),
+		StateTopic:    publisher.StateTopic(sensor.Id),
+		ValueTemplate: fmt.Sprintf("{{ value_json['%s'] }}", valueType),
+		DeviceClass:   homeAssistantDeviceClasses[valueType],
+		StateClass:    "measurement",
+		Device:        device,
+	}
+	if valueType == storage.Rain {
+		sensorConfig.StateClass = "total_increasing"
+	}
+
+	if definition, err := publisher.valueTypeCatalog.GetValueType(valueType); err == nil {
+		sensorConfig.UnitOfMeasurement = definition.Unit
+		if len(definition.Description) != 0 {
+			sensorConfig.Name = definition.Description
+		}
+	}
+	return sensorConfig
+}
+
+func (publisher *MqttPublisher) publish(topic string, retained bool, payload []byte) {
+	token := publisher.mqttClient.Publish(topic, publishQos, retained, payload)
+	go func() {
+		if token.Wait() && token.Error() != nil {
+			log.Printf("publishing to %s failed: %v", topic, token.Error())
+		}
+	}()
+}
+
+func newHomeAssistantDevice(sensor *storage.WeatherSensor) homeAssistantDevice {
+	return homeAssistantDevice{
+		Identifiers:   []string{sensor.Id.String()},
+		Name:          sensor.Name,
+		Model:         sensor.HardwareModel,
+		SwVersion:     sensor.FirmwareVersion,
+		SuggestedArea: sensor.Location,
+	}
+}
+
+func discoveryId(id string) string {
+	return invalidDiscoveryIdCharacters.ReplaceAllString(id, "_")
+}
@@ -21,10 +21,12 @@ var channelBufferSize = 10
 
 type mqttWeatherSource struct {
 	WeatherSourceBase
+	sourceFailure
 	config                   config.MqttConfig
 	mqttClient               mqtt.Client
 	activeSensorMeasurements map[uuid.UUID](chan map[storage.SensorValueType]float64)
 	sensorMutex              sync.RWMutex
+	recorder                 *Recorder
 }
 
 //Close mqtt client
@@ -34,20 +36,22 @@ func (source *mqttWeatherSource) Close() {
 
 //NewMqttSource Factory function for mqttWeatherSource with authentication
 func NewMqttSource(cfg config.MqttConfig) (*mqttWeatherSource, error) {
+	return newRecordingMqttSource(cfg, nil)
+}
+
+//newRecordingMqttSource Factory function for mqttWeatherSource recording all received messages, if the recorder is set
+func newRecordingMqttSource(cfg config.MqttConfig, recorder *Recorder) (*mqttWeatherSource, error) {
 	source := new(mqttWeatherSource)
 	source.config = cfg
+	source.recorder = recorder
+	source.activeSensorMeasurements = make(map[uuid.UUID]chan map[storage.SensorValueType]float64)
+	source.sensorMutex = sync.RWMutex{}
 
-	opts := mqtt.NewClientOptions().AddBroker(cfg.Host)
-
-	//mqtt
-	opts.SetKeepAlive(60 * time.Second)
+	//a lost connection is reported as failure, the source manager reconnects with a new source
+	opts := newMqttClientOptions(cfg)
 	opts.SetDefaultPublishHandler(source.mqttMessageHandler)
-	opts.SetPingTimeout(1 * time.Second)
-
-	if !cfg.AllowAnonymousAuthentication {
-		opts.Username = cfg.Username
-		opts.Password = cfg.Password
-	}
+	opts.SetAutoReconnect(false)
+	opts.SetConnectionLostHandler(func(client mqtt.Client, err error) { source.fail(err) })
 
 	source.mqttClient = mqtt.NewClient(opts)
 
@@ -56,34 +60,40 @@ func NewMqttSource(cfg config.MqttConfig) (*mqttWeatherSource, error) {
 	}
 
 	if token := source.mqttClient.Subscribe(cfg.Topic, 2, nil); token.Wait() && token.Error() != nil {
+		source.mqttClient.Disconnect(0)
 		return nil, token.Error()
 	}
 
-	source.activeSensorMeasurements = make(map[uuid.UUID]chan map[storage.SensorValueType]float64)
-	source.sensorMutex = sync.RWMutex{}
-
 	log.Print("successfully connected to mqtt-broker")
 	return source, nil
 }
 
-//mqttMessageHandler returns a function that handles incoming mqtt-messages
-func (source *mqttWeatherSource) mqttMessageHandler(client mqtt.Client, msg mqtt.Message) {
-	if !regexTopic.MatchString(msg.Topic()) {
-		return
+//newMqttClientOptions returns the connection settings of the mqtt-broker
+func newMqttClientOptions(cfg config.MqttConfig) *mqtt.ClientOptions {
+	opts := mqtt.NewClientOptions().AddBroker(cfg.Host)
+
+	//mqtt
+	opts.SetKeepAlive(60 * time.Second)
+	opts.SetPingTimeout(1 * time.Second)
+
+	if !cfg.AllowAnonymousAuthentication {
+		opts.Username = cfg.Username
+		opts.Password = cfg.Password
 	}
+	return opts
+}
 
-	sensorId, err := uuid.Parse(regexTopic.FindStringSubmatch(msg.Topic())[2])
-	if err != nil {
-		return
+//mqttMessageHandler returns a function that handles incoming mqtt-messages
+func (source *mqttWeatherSource) mqttMessageHandler(client mqtt.Client, msg mqtt.Message) {
+	if source.recorder != nil {
+		source.recorder.RecordMessage(msg.Topic(), msg.Payload())
 	}
 
-	value, err := strconv.ParseFloat(string(msg.Payload()), 64)
-	if err != nil {
+	sensorId, sensorValueType, value, ok := parseMqttMessage(msg.Topic(), msg.Payload())
+	if !ok {
 		return
 	}
 
-	sensorValueType := storage.SensorValueType(regexTopic.FindStringSubmatch(msg.Topic())[3])
-
 	dataValue := map[storage.SensorValueType]float64{
 		sensorValueType: value,
 	}
@@ -106,6 +116,26 @@ func (source *mqttWeatherSource) mqttMessageHandler(client mqtt.Client, msg mqtt
 	}
 }
 
+//parseMqttMessage returns the sensor, value type and value of a message on sensor/<sensorId>/<valueType>
+func parseMqttMessage(topic string, payload []byte) (uuid.UUID, storage.SensorValueType, float64, bool) {
+	matches := regexTopic.FindStringSubmatch(topic)
+	if matches == nil {
+		return uuid.Nil, "", 0, false
+	}
+
+	sensorId, err := uuid.Parse(matches[2])
+	if err != nil {
+		return uuid.Nil, "", 0, false
+	}
+
+	value, err := strconv.ParseFloat(string(payload), 64)
+	if err != nil {
+		return uuid.Nil, "", 0, false
+	}
+
+	return sensorId, storage.SensorValueType(matches[3]), value, true
+}
+
 func (source *mqttWeatherSource) cleanupSensorMeasurement(sensorId uuid.UUID, channel chan<- map[storage.SensorValueType]float64) {
 	time.Sleep(source.config.PublishDelay)
 
@@ -0,0 +1,64 @@
+package weathersource
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"sync"
+	"time"
+	"weather-data/storage"
+)
+
+//replayMessage is a line of a recording with a mqtt message
+type replayMessage struct {
+	Time    time.Time `json:"time"`
+	Topic   string    `json:"topic"`
+	Payload string    `json:"payload"`
+}
+
+//Recorder appends mqtt messages and weather data as json lines to a file, which can be replayed by the replay source
+//weather data is written like the body of POST /sensor/{id}/weather-data with sensorId and timeStamp
+type Recorder struct {
+	file    *os.File
+	encoder *json.Encoder
+	mutex   sync.Mutex
+}
+
+//NewRecorder Factory function for Recorder, an existing file is continued
+func NewRecorder(path string) (*Recorder, error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return nil, err
+	}
+
+	recorder := new(Recorder)
+	recorder.file = file
+	recorder.encoder = json.NewEncoder(file)
+	return recorder, nil
+}
+
+//RecordMessage records a mqtt message with the time of its reception
+func (recorder *Recorder) RecordMessage(topic string, payload []byte) {
+	recorder.record(replayMessage{Time: time.Now(), Topic: topic, Payload: string(payload)})
+}
+
+//RecordWeatherData records weather data with its timestamp
+func (recorder *Recorder) RecordWeatherData(weatherData *storage.WeatherData) {
+	line := weatherData.ToMap()
+	line[storage.TimeStamp] = weatherData.TimeStamp.Format(time.RFC3339Nano)
+	recorder.record(line)
+}
+
+func (recorder *Recorder) record(line interface{}) {
+	recorder.mutex.Lock()
+	defer recorder.mutex.Unlock()
+
+	if err := recorder.encoder.Encode(line); err != nil {
+		log.Print(err)
+	}
+}
+
+//Close the file
+func (recorder *Recorder) Close() {
+	recorder.file.Close()
+}
@@ -0,0 +1,240 @@
+package weathersource
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//replayMaxLineSize is the maximum size of a line of a recording
+var replayMaxLineSize = 1024 * 1024
+
+//replayGroup collects the mqtt messages of a sensor within the publish delay, like the mqtt source
+type replayGroup struct {
+	weatherData *storage.WeatherData
+	first       time.Time
+}
+
+//replayWeatherSource replays a recording of the Recorder, each line is a mqtt message or weather data
+//mqtt messages are grouped to weather data by the recorded times, so a replay is independent of its speed
+type replayWeatherSource struct {
+	WeatherSourceBase
+	sourceFailure
+	config       config.ReplayConfig
+	publishDelay time.Duration
+	file         *os.File
+	done         chan struct{}
+	start        time.Time
+	first        time.Time
+	groups       []*replayGroup
+	passes       int
+}
+
+//NewReplaySource Factory function for replayWeatherSource, mqtt messages are grouped by the publish delay of the mqtt source
+//the replay begins with Start
+func NewReplaySource(cfg config.ReplayConfig, publishDelay time.Duration) (*replayWeatherSource, error) {
+	file, err := os.Open(cfg.File)
+	if err != nil {
+		return nil, err
+	}
+
+	source := new(replayWeatherSource)
+	source.config = cfg
+	source.publishDelay = publishDelay
+	source.file = file
+	source.done = make(chan struct{})
+	return source, nil
+}
+
+//Start begins the replay
+func (source *replayWeatherSource) Start() {
+	go source.run()
+	log.Printf("replaying %v with speed %v", source.config.File, source.config.Speed)
+}
+
+//Close stops the replay
+func (source *replayWeatherSource) Close() {
+	close(source.done)
+	source.file.Close()
+}
+
+//run replays the file until its end or, with loop, until the source is closed
+func (source *replayWeatherSource) run() {
+	for {
+		if err := source.replay(); err != nil {
+			if !source.isClosed() {
+				source.fail(err)
+			}
+			return
+		}
+		source.passes++
+		if !source.config.Loop || source.isClosed() {
+			source.finish()
+			return
+		}
+		if _, err := source.file.Seek(0, io.SeekStart); err != nil {
+			source.fail(err)
+			return
+		}
+	}
+}
+
+func (source *replayWeatherSource) replay() error {
+	source.start = time.Now()
+	source.first = time.Time{}
+	source.groups = nil
+
+	scanner := bufio.NewScanner(source.file)
+	scanner.Buffer(make([]byte, 64*1024), replayMaxLineSize)
+	for lineNumber := 1; scanner.Scan(); lineNumber++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		recordTime, message, weatherData, err := parseReplayLine(line)
+		if err != nil {
+			//a looped recording reports its invalid lines once
+			if source.passes == 0 {
+				log.Printf("skipped line %v of %v: %v", lineNumber, source.config.File, err)
+			}
+			continue
+		}
+
+		if source.first.IsZero() {
+			source.first = recordTime
+		}
+		if !source.waitUntil(recordTime) {
+			return nil
+		}
+		source.publishGroups(recordTime)
+
+		if message != nil {
+			source.addMessage(recordTime, message)
+		} else {
+			weatherData.TimeStamp = source.timeStamp(recordTime)
+			source.NewWeatherData(weatherData)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	source.publishGroups(time.Time{})
+	return nil
+}
+
+//parseReplayLine returns a mqtt message or weather data with the recorded time
+func parseReplayLine(line []byte) (time.Time, *replayMessage, *storage.WeatherData, error) {
+	values := make(map[string]interface{})
+	if err := json.Unmarshal(line, &values); err != nil {
+		return time.Time{}, nil, nil, err
+	}
+
+	if _, isMessage := values["topic"]; isMessage {
+		message := new(replayMessage)
+		if err := json.Unmarshal(line, message); err != nil {
+			return time.Time{}, nil, nil, err
+		}
+		if message.Time.IsZero() {
+			return time.Time{}, nil, nil, errors.New("missing time")
+		}
+		return message.Time, message, nil, nil
+	}
+
+	weatherData, err := storage.FromMap(values)
+	if err != nil {
+		return time.Time{}, nil, nil, err
+	}
+	if weatherData.TimeStamp.IsZero() || weatherData.SensorId == uuid.Nil {
+		return time.Time{}, nil, nil, errors.New("missing sensorId or timeStamp")
+	}
+	return weatherData.TimeStamp, nil, weatherData, nil
+}
+
+//waitUntil waits for the replay time of the recorded time, false if the source was closed
+func (source *replayWeatherSource) waitUntil(recordTime time.Time) bool {
+	if source.config.Speed <= 0 {
+		return !source.isClosed()
+	}
+
+	wait := time.Until(source.replayTime(recordTime))
+	if wait <= 0 {
+		return !source.isClosed()
+	}
+
+	select {
+	case <-source.done:
+		return false
+	case <-time.After(wait):
+		return true
+	}
+}
+
+//replayTime is the time a recorded time is replayed at, earlier records than the first are replayed immediately
+func (source *replayWeatherSource) replayTime(recordTime time.Time) time.Time {
+	return source.start.Add(time.Duration(float64(recordTime.Sub(source.first)) / source.config.Speed))
+}
+
+//timeStamp returns the timestamp of replayed weather data
+func (source *replayWeatherSource) timeStamp(recordTime time.Time) time.Time {
+	if source.config.KeepTimeStamps {
+		return recordTime
+	}
+	if source.config.Speed <= 0 {
+		return time.Now()
+	}
+	return source.replayTime(recordTime)
+}
+
+//addMessage adds the value of the message to the open group of its sensor
+func (source *replayWeatherSource) addMessage(recordTime time.Time, message *replayMessage) {
+	sensorId, sensorValueType, value, ok := parseMqttMessage(message.Topic, []byte(message.Payload))
+	if !ok {
+		return
+	}
+
+	for _, group := range source.groups {
+		if group.weatherData.SensorId == sensorId {
+			group.weatherData.Values[sensorValueType] = value
+			return
+		}
+	}
+
+	weatherData := storage.NewWeatherData()
+	weatherData.SensorId = sensorId
+	weatherData.Values[sensorValueType] = value
+	source.groups = append(source.groups, &replayGroup{weatherData: weatherData, first: recordTime})
+}
+
+//publishGroups publishes the groups opened more than the publish delay before the recorded time, all groups for a zero time
+func (source *replayWeatherSource) publishGroups(recordTime time.Time) {
+	open := source.groups[:0]
+	for _, group := range source.groups {
+		if recordTime.IsZero() || !recordTime.Before(group.first.Add(source.publishDelay)) {
+			group.weatherData.TimeStamp = source.timeStamp(group.first)
+			source.NewWeatherData(group.weatherData)
+		} else {
+			open = append(open, group)
+		}
+	}
+	source.groups = open
+}
+
+func (source *replayWeatherSource) isClosed() bool {
+	select {
+	case <-source.done:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,285 @@
+package weathersource
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	mqtt "github.com/eclipse/paho.mqtt.golang"
+)
+
+//rtl433TimeFormat is the default format of the time field, in the local time of the receiver
+var rtl433TimeFormat = "2006-01-02 15:04:05"
+
+//rtl433FollowInterval is the interval in which a file is checked for new events after reaching its end
+var rtl433FollowInterval = time.Second
+
+//rtl433Field maps a field of the rtl_433 events to a value type, values are converted from the unit to the unit of the catalog
+type rtl433Field struct {
+	valueType storage.SensorValueType
+	unit      storage.Unit
+}
+
+//rtl433Fields are the known fields of the rtl_433 weather sensor decoders
+var rtl433Fields = map[string]rtl433Field{
+	"temperature_C":  {storage.Temperature, storage.Celsius},
+	"temperature_F":  {storage.Temperature, storage.Fahrenheit},
+	"humidity":       {storage.Humidity, ""},
+	"pressure_hPa":   {storage.Pressure, storage.Hectopascal},
+	"pressure_kPa":   {storage.Pressure, storage.Kilopascal},
+	"wind_avg_km_h":  {storage.WindSpeed, storage.KilometerPerHour},
+	"wind_avg_m_s":   {storage.WindSpeed, storage.MeterPerSecond},
+	"wind_avg_mi_h":  {storage.WindSpeed, storage.MilesPerHour},
+	"wind_max_km_h":  {storage.WindGust, storage.KilometerPerHour},
+	"wind_max_m_s":   {storage.WindGust, storage.MeterPerSecond},
+	"wind_max_mi_h":  {storage.WindGust, storage.MilesPerHour},
+	"wind_dir_deg":   {storage.WindDirection, ""},
+	"rain_mm":        {storage.Rain, storage.Millimeter},
+	"rain_in":        {storage.Rain, storage.Inch},
+	"rain_rate_mm_h": {storage.RainRate, storage.MillimeterPerHour},
+	"rain_rate_in_h": {storage.RainRate, storage.InchPerHour},
+	"uv":             {storage.UvIndex, ""},
+	"uvi":            {storage.UvIndex, ""},
+	"co2_ppm":        {storage.Co2Level, ""},
+	"pm2_5_ug_m3":    {storage.Pm25, ""},
+	"pm10_ug_m3":     {storage.Pm10, ""},
+}
+
+//rtl433WeatherSource reads the json events of rtl_433 and maps the devices to registered sensors by their external id model/id/channel
+type rtl433WeatherSource struct {
+	WeatherSourceBase
+	sourceFailure
+	config           config.Rtl433Config
+	sensorRegistry   storage.SensorRegistry
+	valueTypeCatalog storage.ValueTypeCatalog
+	unmapped         map[string]bool
+	mutex            sync.Mutex
+	done             chan struct{}
+	closer           io.Closer
+	mqttClient       mqtt.Client
+}
+
+//NewRtl433Source Factory function for rtl433WeatherSource, the events are read from stdin, a file, a udp socket or a mqtt topic
+func NewRtl433Source(cfg config.Rtl433Config, mqttCfg config.MqttConfig, sensorRegistry storage.SensorRegistry, valueTypeCatalog storage.ValueTypeCatalog) (*rtl433WeatherSource, error) {
+	source := new(rtl433WeatherSource)
+	source.config = cfg
+	source.sensorRegistry = sensorRegistry
+	source.valueTypeCatalog = valueTypeCatalog
+	source.unmapped = make(map[string]bool)
+	source.done = make(chan struct{})
+
+	switch cfg.Input {
+	case "stdin":
+		go source.readLines(os.Stdin, false)
+	case "file":
+		file, err := os.Open(cfg.File)
+		if err != nil {
+			return nil, err
+		}
+		source.closer = file
+		go source.readLines(file, true)
+	case "udp":
+		connection, err := net.ListenPacket("udp", cfg.UdpAddress)
+		if err != nil {
+			return nil, err
+		}
+		source.closer = connection
+		go source.readDatagrams(connection)
+	case "mqtt":
+		opts := newMqttClientOptions(mqttCfg)
+		opts.SetAutoReconnect(false)
+		opts.SetConnectionLostHandler(func(client mqtt.Client, err error) { source.fail(err) })
+		source.mqttClient = mqtt.NewClient(opts)
+		if token := source.mqttClient.Connect(); token.Wait() && token.Error() != nil {
+			return nil, token.Error()
+		}
+		handler := func(client mqtt.Client, msg mqtt.Message) { source.handleEvent(msg.Payload()) }
+		if token := source.mqttClient.Subscribe(cfg.MqttTopic, 0, handler); token.Wait() && token.Error() != nil {
+			source.mqttClient.Disconnect(0)
+			return nil, token.Error()
+		}
+	default:
+		return nil, fmt.Errorf("unknown rtl_433 input %v", cfg.Input)
+	}
+
+	log.Printf("reading rtl_433 events from %v", cfg.Input)
+	return source, nil
+}
+
+//Close stops reading events
+func (source *rtl433WeatherSource) Close() {
+	close(source.done)
+	if source.closer != nil {
+		source.closer.Close()
+	}
+	if source.mqttClient != nil {
+		source.mqttClient.Disconnect(2)
+	}
+}
+
+//readLines reads one event per line, a followed file is polled for new lines after reaching its end
+func (source *rtl433WeatherSource) readLines(reader io.Reader, follow bool) {
+	bufferedReader := bufio.NewReader(reader)
+	var line []byte
+	for {
+		chunk, err := bufferedReader.ReadBytes('\n')
+		line = append(line, chunk...)
+		if err == nil {
+			source.handleEvent(line)
+			line = nil
+			continue
+		}
+		if err != io.EOF || !follow {
+			if !source.isClosed() {
+				source.fail(err)
+			}
+			return
+		}
+		select {
+		case <-source.done:
+			return
+		case <-time.After(rtl433FollowInterval):
+		}
+	}
+}
+
+//readDatagrams reads one event per datagram, the syslog header of the rtl_433 udp output is skipped
+func (source *rtl433WeatherSource) readDatagrams(connection net.PacketConn) {
+	buffer := make([]byte, 65536)
+	for {
+		n, _, err := connection.ReadFrom(buffer)
+		if err != nil {
+			if !source.isClosed() {
+				source.fail(err)
+			}
+			return
+		}
+		if start := bytes.IndexByte(buffer[:n], '{'); start >= 0 {
+			source.handleEvent(buffer[start:n])
+		}
+	}
+}
+
+func (source *rtl433WeatherSource) isClosed() bool {
+	select {
+	case <-source.done:
+		return true
+	default:
+		return false
+	}
+}
+
+//handleEvent converts an event of a mapped device to WeatherData, events of other devices are ignored
+func (source *rtl433WeatherSource) handleEvent(payload []byte) {
+	decoder := json.NewDecoder(bytes.NewReader(payload))
+	decoder.UseNumber()
+
+	event := make(map[string]interface{})
+	if err := decoder.Decode(&event); err != nil {
+		return
+	}
+
+	key, ok := rtl433DeviceKey(event)
+	if !ok {
+		return
+	}
+
+	sensor, err := source.sensorRegistry.GetSensorByExternalId(storage.Rtl433Protocol, key)
+	if err != nil {
+		source.logUnmapped(key)
+		return
+	}
+
+	definitions, err := source.valueTypeCatalog.GetValueTypes()
+	if err != nil {
+		log.Print(err)
+		return
+	}
+
+	weatherData := storage.NewWeatherData()
+	weatherData.SensorId = sensor.Id
+	weatherData.TimeStamp = rtl433TimeStamp(event["time"])
+
+	for name, field := range rtl433Fields {
+		number, isNumber := event[name].(json.Number)
+		if !isNumber {
+			continue
+		}
+		value, err := number.Float64()
+		if err != nil {
+			continue
+		}
+		if len(field.unit) != 0 {
+			if value, err = storage.ConvertToCatalogUnit(value, field.unit, field.valueType, definitions); err != nil {
+				continue
+			}
+		}
+		weatherData.Values[field.valueType] = value
+	}
+
+	if len(weatherData.Values) != 0 {
+		source.NewWeatherData(weatherData)
+	}
+}
+
+//logUnmapped logs each unmapped device once, so the key can be added as external id of a sensor
+func (source *rtl433WeatherSource) logUnmapped(key string) {
+	source.mutex.Lock()
+	defer source.mutex.Unlock()
+
+	if !source.unmapped[key] {
+		source.unmapped[key] = true
+		log.Printf("received rtl_433 events of unmapped device %v", key)
+	}
+}
+
+//rtl433DeviceKey returns the external id of the device which sent the event: model/id or model/id/channel
+func rtl433DeviceKey(event map[string]interface{}) (string, bool) {
+	model, hasModel := event["model"].(string)
+	id, hasId := event["id"]
+	if !hasModel || !hasId {
+		return "", false
+	}
+
+	parts := []string{model, fmt.Sprint(id)}
+	if channel, hasChannel := event["channel"]; hasChannel {
+		parts = append(parts, fmt.Sprint(channel))
+	}
+	return strings.Join(parts, "/"), true
+}
+
+//rtl433TimeStamp parses the time of the event in the default, iso or unix format, the time of receipt is used if it is missing or unknown
+func rtl433TimeStamp(value interface{}) time.Time {
+	switch timeValue := value.(type) {
+	case string:
+		if timeStamp, err := time.ParseInLocation(rtl433TimeFormat, timeValue, time.Local); err == nil {
+			return timeStamp
+		}
+		if timeStamp, err := time.Parse(time.RFC3339, timeValue); err == nil {
+			return timeStamp
+		}
+		if seconds, err := strconv.ParseFloat(timeValue, 64); err == nil {
+			return unixTimeStamp(seconds)
+		}
+	case json.Number:
+		if seconds, err := timeValue.Float64(); err == nil {
+			return unixTimeStamp(seconds)
+		}
+	}
+	return time.Now()
+}
+
+func unixTimeStamp(seconds float64) time.Time {
+	return time.Unix(0, int64(seconds*float64(time.Second)))
+}
@@ -0,0 +1,292 @@
+package weathersource
+
+import (
+	"fmt"
+	"log"
+	"sync"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+)
+
+//minRestartDelay is the delay before the first restart of a failed source, it doubles with every failed attempt up to maxRestartDelay
+var minRestartDelay = time.Second
+
+var maxRestartDelay = 5 * time.Minute
+
+//SourceState is the state of a supervised source instance
+type SourceState string
+
+const (
+	SourceStarting SourceState = "starting"
+	SourceRunning  SourceState = "running"
+	SourceFailed   SourceState = "failed"
+	SourceStopped  SourceState = "stopped"
+)
+
+//SourceHealth reports the state of a source instance
+type SourceHealth struct {
+	Name        string
+	Type        string
+	State       SourceState
+	Since       time.Time //time of the last state change
+	Error       string    `json:",omitempty"`
+	Restarts    int
+	DataCount   int64
+	LastData    *time.Time `json:",omitempty"`
+	NextRestart *time.Time `json:",omitempty"`
+}
+
+//SourceHealthReporter reports the health of the configured sources
+type SourceHealthReporter interface {
+	Health() []SourceHealth
+}
+
+//failingSource is implemented by sources that can stop working after they were started
+//a nil error reports a source that finished its work and is not restarted
+type failingSource interface {
+	Failed() <-chan error
+}
+
+//startableSource is implemented by sources that are started after the manager registered for their weather data
+type startableSource interface {
+	Start()
+}
+
+//sourceFailure reports the first error that stops a started source to the SourceManager
+type sourceFailure struct {
+	init   sync.Once
+	failed chan error
+}
+
+//Failed returns a channel receiving the error that stopped the source
+func (failure *sourceFailure) Failed() <-chan error {
+	return failure.channel()
+}
+
+func (failure *sourceFailure) fail(err error) {
+	select {
+	case failure.channel() <- err:
+	default:
+	}
+}
+
+//finish reports that the source finished its work
+func (failure *sourceFailure) finish() {
+	failure.fail(nil)
+}
+
+func (failure *sourceFailure) channel() chan error {
+	failure.init.Do(func() { failure.failed = make(chan error, 1) })
+	return failure.failed
+}
+
+//managedSource is a source instance with its health
+type managedSource struct {
+	config config.SourceConfig
+	health SourceHealth
+}
+
+//SourceManager starts the configured sources and restarts each of them independently after a failure
+//the weather data of all sources is passed on by the manager
+type SourceManager struct {
+	WeatherSourceBase
+	sensorRegistry   storage.SensorRegistry
+	valueTypeCatalog storage.ValueTypeCatalog
+	sources          []*managedSource
+	recorders        map[string]*Recorder
+	mutex            sync.RWMutex
+	done             chan struct{}
+	waitGroup        sync.WaitGroup
+}
+
+//NewSourceManager Factory function for SourceManager, the sources are started by Start
+func NewSourceManager(configs []config.SourceConfig, sensorRegistry storage.SensorRegistry, valueTypeCatalog storage.ValueTypeCatalog) *SourceManager {
+	manager := new(SourceManager)
+	manager.sensorRegistry = sensorRegistry
+	manager.valueTypeCatalog = valueTypeCatalog
+	manager.done = make(chan struct{})
+	manager.recorders = make(map[string]*Recorder)
+
+	for _, cfg := range configs {
+		manager.sources = append(manager.sources, &managedSource{
+			config: cfg,
+			health: SourceHealth{Name: cfg.Name, Type: cfg.Type, State: SourceStopped, Since: time.Now()},
+		})
+	}
+	return manager
+}
+
+//Start starts the supervision of all sources, a source failing to start does not affect the others
+func (manager *SourceManager) Start() {
+	for _, source := range manager.sources {
+		manager.waitGroup.Add(1)
+		go manager.supervise(source)
+	}
+}
+
+//Close stops all sources
+func (manager *SourceManager) Close() {
+	close(manager.done)
+	manager.waitGroup.Wait()
+
+	for _, recorder := range manager.recorders {
+		recorder.Close()
+	}
+}
+
+//Health returns the health of all sources in the configured order
+func (manager *SourceManager) Health() []SourceHealth {
+	manager.mutex.RLock()
+	defer manager.mutex.RUnlock()
+
+	health := make([]SourceHealth, len(manager.sources))
+	for i, source := range manager.sources {
+		health[i] = source.health
+	}
+	return health
+}
+
+//supervise starts the source and restarts it with an increasing delay until the manager is closed
+//the delay is reset once a source was running for maxRestartDelay
+func (manager *SourceManager) supervise(source *managedSource) {
+	defer manager.waitGroup.Done()
+
+	delay := minRestartDelay
+	for {
+		manager.setState(source, SourceStarting, nil)
+		recorder, err := manager.recorder(source.config.RecordFile)
+		var instance WeatherSource
+		if err == nil {
+			instance, err = manager.newSource(source.config, recorder)
+		}
+		if err == nil {
+			manager.setState(source, SourceRunning, nil)
+			//mqtt sources record the messages instead of the weather data
+			if source.config.Type == config.MqttSource {
+				recorder = nil
+			}
+			instance.OnNewWeatherData(func(weatherData *storage.WeatherData) { manager.handleWeatherData(source, recorder, weatherData) })
+			if startable, ok := instance.(startableSource); ok {
+				startable.Start()
+			}
+
+			started := time.Now()
+			var failed <-chan error
+			if failing, ok := instance.(failingSource); ok {
+				failed = failing.Failed()
+			}
+
+			select {
+			case <-manager.done:
+				instance.Close()
+				manager.setState(source, SourceStopped, nil)
+				return
+			case err = <-failed:
+				instance.Close()
+			}
+
+			if err == nil {
+				log.Printf("source %v finished", source.config.Name)
+				manager.setState(source, SourceStopped, nil)
+				<-manager.done
+				return
+			}
+
+			if time.Since(started) >= maxRestartDelay {
+				delay = minRestartDelay
+			}
+		}
+
+		log.Printf("source %v failed: %v, restarting in %v", source.config.Name, err, delay)
+		manager.setState(source, SourceFailed, err)
+		manager.mutex.Lock()
+		nextRestart := time.Now().Add(delay)
+		source.health.NextRestart = &nextRestart
+		manager.mutex.Unlock()
+
+		select {
+		case <-manager.done:
+			manager.setState(source, SourceStopped, err)
+			return
+		case <-time.After(delay):
+		}
+
+		manager.mutex.Lock()
+		source.health.Restarts++
+		manager.mutex.Unlock()
+
+		if delay *= 2; delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+	}
+}
+
+func (manager *SourceManager) setState(source *managedSource, state SourceState, err error) {
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	source.health.State = state
+	source.health.Since = time.Now()
+	source.health.Error = ""
+	if err != nil {
+		source.health.Error = err.Error()
+	}
+	source.health.NextRestart = nil
+}
+
+func (manager *SourceManager) handleWeatherData(source *managedSource, recorder *Recorder, weatherData *storage.WeatherData) {
+	if recorder != nil {
+		recorder.RecordWeatherData(weatherData)
+	}
+
+	manager.mutex.Lock()
+	now := time.Now()
+	source.health.DataCount++
+	source.health.LastData = &now
+	manager.mutex.Unlock()
+
+	manager.NewWeatherData(weatherData)
+}
+
+//recorder returns the recorder of the file, sources recording to the same file share the recorder
+func (manager *SourceManager) recorder(path string) (*Recorder, error) {
+	if len(path) == 0 {
+		return nil, nil
+	}
+
+	manager.mutex.Lock()
+	defer manager.mutex.Unlock()
+
+	if recorder, exists := manager.recorders[path]; exists {
+		return recorder, nil
+	}
+	recorder, err := NewRecorder(path)
+	if err != nil {
+		return nil, err
+	}
+	manager.recorders[path] = recorder
+	return recorder, nil
+}
+
+//newSource creates a source of the configured type
+func (manager *SourceManager) newSource(cfg config.SourceConfig, recorder *Recorder) (WeatherSource, error) {
+	switch cfg.Type {
+	case config.MqttSource:
+		return newRecordingMqttSource(cfg.Mqtt, recorder)
+	case config.LineProtocolSource:
+		return NewLineProtocolSource(cfg.LineProtocol)
+	case config.CoapSource:
+		return NewCoapSource(cfg.Coap, manager.sensorRegistry)
+	case config.Rtl433Source:
+		return NewRtl433Source(cfg.Rtl433, cfg.Mqtt, manager.sensorRegistry, manager.valueTypeCatalog)
+	case config.HttpSource:
+		return NewHttpSource(cfg.Http)
+	case config.ReplaySource:
+		return NewReplaySource(cfg.Replay, cfg.Mqtt.PublishDelay)
+	case config.SyntheticSource:
+		return NewSyntheticSource(cfg.Synthetic, manager.sensorRegistry)
+	default:
+		return nil, fmt.Errorf("unknown source type %v", cfg.Type)
+	}
+}
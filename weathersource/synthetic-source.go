@@ -0,0 +1,72 @@
+package weathersource
+
+import (
+	"log"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//syntheticWeatherSource feeds synthetic weather data of virtual sensors in an interval
+//registered sensors get the weather of their location
+type syntheticWeatherSource struct {
+	WeatherSourceBase
+	interval time.Duration
+	weathers []*storage.SyntheticWeather
+	done     chan struct{}
+}
+
+//NewSyntheticSource Factory function for syntheticWeatherSource, the data is generated after Start
+func NewSyntheticSource(cfg config.SyntheticConfig, sensorRegistry storage.SensorRegistry) (*syntheticWeatherSource, error) {
+	source := new(syntheticWeatherSource)
+	source.interval = time.Duration(cfg.Interval) * time.Second
+	source.done = make(chan struct{})
+
+	for _, id := range cfg.SensorIds {
+		sensorId, err := uuid.Parse(id)
+		if err != nil {
+			return nil, err
+		}
+
+		latitude, longitude := storage.DefaultSyntheticLatitude, storage.DefaultSyntheticLongitude
+		if sensor, err := sensorRegistry.GetSensor(sensorId); err == nil {
+			latitude, longitude = sensor.Latitude, sensor.Longitude
+		}
+		source.weathers = append(source.weathers, storage.NewSyntheticWeather(sensorId, latitude, longitude))
+	}
+
+	return source, nil
+}
+
+//Start begins generating weather data
+func (source *syntheticWeatherSource) Start() {
+	go source.run()
+	log.Printf("generating synthetic weather data of %v sensors every %v", len(source.weathers), source.interval)
+}
+
+//Close stops generating weather data
+func (source *syntheticWeatherSource) Close() {
+	close(source.done)
+}
+
+//run generates the weather data at start and then at each multiple of the interval
+func (source *syntheticWeatherSource) run() {
+	source.generate(time.Now().Truncate(time.Second))
+	for {
+		next := time.Now().Truncate(source.interval).Add(source.interval)
+		select {
+		case <-source.done:
+			return
+		case <-time.After(time.Until(next)):
+			source.generate(next)
+		}
+	}
+}
+
+func (source *syntheticWeatherSource) generate(timeStamp time.Time) {
+	for _, weather := range source.weathers {
+		source.NewWeatherData(weather.At(timeStamp))
+	}
+}
@@ -0,0 +1,171 @@
+package webhook
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+const (
+	SignatureHeader = "X-Webhook-Signature-256"
+	DeliveryHeader  = "X-Webhook-Delivery"
+)
+
+//deliveryWorkers is the number of parallel deliveries, so a slow subscriber does not block all others
+var deliveryWorkers = 4
+
+type pendingDelivery struct {
+	delivery *storage.WebhookDelivery
+	url      string
+	secret   string
+	body     []byte
+}
+
+//deliveryWorker posts the signed deliveries, retries failed ones with increasing delay and logs the outcome
+type deliveryWorker struct {
+	registry storage.WebhookRegistry
+	config   config.NotificationConfig
+	client   *http.Client
+	queue    chan pendingDelivery
+	done     chan struct{}
+	closed   bool
+	mutex    sync.RWMutex
+	wg       sync.WaitGroup
+}
+
+func newDeliveryWorker(registry storage.WebhookRegistry, cfg config.NotificationConfig) *deliveryWorker {
+	worker := new(deliveryWorker)
+	worker.registry = registry
+	worker.config = cfg
+	worker.client = storage.NewWebhookClient(cfg.Timeout, cfg.AllowPrivateNetworks)
+	worker.queue = make(chan pendingDelivery, cfg.QueueSize)
+	worker.done = make(chan struct{})
+
+	for i := 0; i < deliveryWorkers; i++ {
+		worker.wg.Add(1)
+		go worker.run()
+	}
+	return worker
+}
+
+//enqueue queues the delivery, it fails if the queue is full
+func (worker *deliveryWorker) enqueue(subscription *storage.WebhookSubscription, data *storage.WeatherData, payload interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	delivery := &storage.WebhookDelivery{
+		Id:             uuid.New(),
+		SubscriptionId: subscription.Id,
+		SensorId:       data.SensorId,
+		DataTimeStamp:  data.TimeStamp,
+		CreatedAt:      time.Now(),
+	}
+
+	worker.mutex.RLock()
+	defer worker.mutex.RUnlock()
+	if worker.closed {
+		return errors.New("delivery worker is closed")
+	}
+
+	select {
+	case worker.queue <- pendingDelivery{delivery: delivery, url: subscription.Url, secret: subscription.Secret, body: body}:
+		return nil
+	default:
+		delivery.State = storage.Failed
+		delivery.Error = "delivery queue is full"
+		worker.registry.SaveDelivery(delivery)
+		return errors.New(delivery.Error)
+	}
+}
+
+func (worker *deliveryWorker) close() {
+	worker.mutex.Lock()
+	if worker.closed {
+		worker.mutex.Unlock()
+		return
+	}
+	worker.closed = true
+	close(worker.done)
+	close(worker.queue)
+	worker.mutex.Unlock()
+
+	worker.wg.Wait()
+}
+
+func (worker *deliveryWorker) run() {
+	defer worker.wg.Done()
+	for pending := range worker.queue {
+		worker.deliver(pending)
+		worker.registry.SaveDelivery(pending.delivery)
+	}
+}
+
+//deliver posts the delivery until it succeeds or the retries are exhausted, the outcome is stored in the delivery
+func (worker *deliveryWorker) deliver(pending pendingDelivery) {
+	delivery := pending.delivery
+	delay := worker.config.RetryDelay
+	for attempt := 0; attempt <= worker.config.Retries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-time.After(delay):
+				delay *= 2
+			case <-worker.done:
+				delivery.State = storage.Failed
+				delivery.Error = fmt.Sprintf("cancelled after %v attempts: %v", attempt, delivery.Error)
+				return
+			}
+		}
+
+		delivery.Attempts = attempt + 1
+		statusCode, err := worker.post(pending)
+		delivery.StatusCode = statusCode
+		if err == nil {
+			delivery.State = storage.Delivered
+			delivery.Error = ""
+			return
+		}
+		delivery.Error = err.Error()
+	}
+	delivery.State = storage.Failed
+}
+
+func (worker *deliveryWorker) post(pending pendingDelivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, pending.url, bytes.NewReader(pending.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("content-type", "application/json")
+	req.Header.Set(DeliveryHeader, pending.delivery.Id.String())
+	req.Header.Set(SignatureHeader, Sign(pending.body, pending.secret))
+
+	resp, err := worker.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return resp.StatusCode, fmt.Errorf("unexpected status %v", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+//Sign returns the HMAC-SHA256 signature of the body in the format sha256=<hex>
+func Sign(body []byte, secret string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
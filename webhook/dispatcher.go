@@ -0,0 +1,63 @@
+package webhook
+
+import (
+	"log"
+	"weather-data/config"
+	"weather-data/storage"
+
+	"github.com/google/uuid"
+)
+
+//AccessFunc checks if the user is allowed to read the weather data of the sensor
+type AccessFunc func(sensorId uuid.UUID, userId string) bool
+
+//Payload is the body of a delivery
+type Payload struct {
+	SubscriptionId uuid.UUID              `json:"subscriptionId"`
+	Data           map[string]interface{} `json:"data"`
+}
+
+//Dispatcher matches new weather data with the webhook subscriptions and queues the deliveries
+type Dispatcher struct {
+	registry storage.WebhookRegistry
+	canRead  AccessFunc
+	worker   *deliveryWorker
+}
+
+//NewDispatcher Factory, the access is checked on every delivery so revoked read access stops the deliveries
+func NewDispatcher(registry storage.WebhookRegistry, canRead AccessFunc, cfg config.NotificationConfig) *Dispatcher {
+	dispatcher := new(Dispatcher)
+	dispatcher.registry = registry
+	dispatcher.canRead = canRead
+	dispatcher.worker = newDeliveryWorker(registry, cfg)
+	return dispatcher
+}
+
+//HandleWeatherData queues a delivery for each matching subscription, intended to be registered with OnNewWeatherData
+func (dispatcher *Dispatcher) HandleWeatherData(data *storage.WeatherData) {
+	subscriptions, err := dispatcher.registry.GetSubscriptionsOfSensor(data.SensorId)
+	if err != nil {
+		return
+	}
+
+	for _, subscription := range subscriptions {
+		if subscription.Disabled || !dispatcher.canRead(data.SensorId, subscription.UserId) {
+			continue
+		}
+
+		filtered, hasValues := subscription.Filter(data)
+		if !hasValues {
+			continue
+		}
+
+		payload := Payload{SubscriptionId: subscription.Id, Data: filtered.ToMap()}
+		if err := dispatcher.worker.enqueue(subscription, filtered, payload); err != nil {
+			log.Printf("delivery to webhook %v dropped: %v", subscription.Id, err)
+		}
+	}
+}
+
+//Close stops the delivery worker, pending retries are cancelled
+func (dispatcher *Dispatcher) Close() {
+	dispatcher.worker.close()
+}